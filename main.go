@@ -6,10 +6,17 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"os"
 
+	"github.com/rancher/terraform-controller/pkg/admission"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/generated/clientset/versioned/scheme"
 	"github.com/rancher/terraform-controller/pkg/generated/controllers/terraformcontroller.cattle.io"
 	"github.com/rancher/terraform-controller/pkg/terraform"
+	"github.com/rancher/terraform-controller/pkg/terraform/state"
+	"github.com/rancher/terraform-controller/pkg/webhook"
 	"github.com/rancher/wrangler/pkg/generated/controllers/batch"
 	"github.com/rancher/wrangler/pkg/generated/controllers/core"
 	"github.com/rancher/wrangler/pkg/generated/controllers/rbac"
@@ -18,7 +25,11 @@ import (
 	"github.com/rancher/wrangler/pkg/start"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 )
 
 var (
@@ -55,6 +66,98 @@ func main() {
 			EnvVar: "MASTERURL",
 			Value:  "",
 		},
+		cli.StringFlag{
+			Name:   "webhook-listen",
+			EnvVar: "WEBHOOK_LISTEN",
+			Usage:  "Address to receive git push webhooks on, e.g. :8080, empty to disable",
+		},
+		cli.StringFlag{
+			Name:   "webhook-secret",
+			EnvVar: "WEBHOOK_SECRET",
+			Usage:  "Shared secret git push webhooks are signed with, required by --webhook-listen",
+		},
+		cli.StringFlag{
+			Name:   "terraform-mirror",
+			EnvVar: "TERRAFORM_MIRROR",
+			Usage:  "URL executors download terraform releases from, defaults to https://releases.hashicorp.com/terraform",
+		},
+		cli.StringFlag{
+			Name:   "tofu-mirror",
+			EnvVar: "TOFU_MIRROR",
+			Usage:  "URL executors download OpenTofu releases from, defaults to https://github.com/opentofu/opentofu/releases/download",
+		},
+		cli.StringFlag{
+			Name:   "executor-cache-claim",
+			EnvVar: "EXECUTOR_CACHE_CLAIM",
+			Usage:  "Persistent volume claim mounted into executor jobs to cache terraform releases and providers",
+		},
+		cli.StringFlag{
+			Name:   "provider-network-mirror",
+			EnvVar: "PROVIDER_NETWORK_MIRROR",
+			Usage:  "URL of a provider network mirror terraform init installs providers from",
+		},
+		cli.StringFlag{
+			Name:   "provider-filesystem-mirror",
+			EnvVar: "PROVIDER_FILESYSTEM_MIRROR",
+			Usage:  "Directory in the executor image terraform init installs providers from",
+		},
+		cli.BoolFlag{
+			Name:   "provider-direct",
+			EnvVar: "PROVIDER_DIRECT",
+			Usage:  "Install providers missing from the mirrors from their origin registries",
+		},
+		cli.StringFlag{
+			Name:   "snapshot-store",
+			EnvVar: "SNAPSHOT_STORE",
+			Usage:  "Where executors save a copy of the state after each apply: secret, s3 or none",
+			Value:  "secret",
+		},
+		cli.IntFlag{
+			Name:   "snapshot-retention",
+			EnvVar: "SNAPSHOT_RETENTION",
+			Usage:  "Number of state snapshots kept per state",
+			Value:  10,
+		},
+		cli.StringFlag{
+			Name:   "snapshot-s3-endpoint",
+			EnvVar: "SNAPSHOT_S3_ENDPOINT",
+			Usage:  "URL of the S3 compatible store snapshots are saved to, e.g. https://s3.us-west-2.amazonaws.com",
+		},
+		cli.StringFlag{
+			Name:   "snapshot-s3-region",
+			EnvVar: "SNAPSHOT_S3_REGION",
+			Usage:  "Region of the snapshot bucket, defaults to us-east-1",
+		},
+		cli.StringFlag{
+			Name:   "snapshot-s3-bucket",
+			EnvVar: "SNAPSHOT_S3_BUCKET",
+			Usage:  "Bucket snapshots are saved to",
+		},
+		cli.StringFlag{
+			Name:   "snapshot-s3-secret",
+			EnvVar: "SNAPSHOT_S3_SECRET",
+			Usage:  "Secret in each state's namespace holding the accessKeyID and secretAccessKey of the snapshot bucket",
+		},
+		cli.StringFlag{
+			Name:   "admission-listen",
+			EnvVar: "ADMISSION_LISTEN",
+			Usage:  "Address to serve the admission webhooks on over TLS, empty to disable",
+		},
+		cli.StringFlag{
+			Name:   "admission-cert-file",
+			EnvVar: "ADMISSION_CERT_FILE",
+			Usage:  "TLS certificate of the admission webhooks, a self-signed certificate is generated and the webhooks registered when empty",
+		},
+		cli.StringFlag{
+			Name:   "admission-key-file",
+			EnvVar: "ADMISSION_KEY_FILE",
+			Usage:  "TLS key of the admission webhooks",
+		},
+		cli.StringFlag{
+			Name:   "admission-controller-user",
+			EnvVar: "ADMISSION_CONTROLLER_USER",
+			Usage:  "User the controller runs as, the only one allowed to record approvals, defaults to the terraform-controller service account of the namespace",
+		},
 	}
 	app.Action = run
 
@@ -114,18 +217,94 @@ func run(c *cli.Context) {
 		logrus.Fatalf("Error building rbac controllers: %s", err.Error())
 	}
 
+	k8s, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		logrus.Fatalf("Error building kubernetes client: %s", err.Error())
+	}
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k8s.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "terraform-controller"})
+
 	terraform.Register(ctx,
 		tfFactory.Terraformcontroller().V1().Module(),
 		tfFactory.Terraformcontroller().V1().State(),
 		tfFactory.Terraformcontroller().V1().Execution(),
+		tfFactory.Terraformcontroller().V1().NotificationConfig(),
 		rbacFactory.Rbac().V1().ClusterRole(),
 		rbacFactory.Rbac().V1().ClusterRoleBinding(),
 		coreFactory.Core().V1().Secret(),
 		coreFactory.Core().V1().ConfigMap(),
 		coreFactory.Core().V1().ServiceAccount(),
 		batchFactory.Batch().V1().Job(),
+		k8s.CoordinationV1(),
+		recorder,
+		state.Options{
+			TerraformMirror: c.String("terraform-mirror"),
+			TofuMirror:      c.String("tofu-mirror"),
+			CacheClaim:      c.String("executor-cache-claim"),
+			Providers: v1.ProviderInstallation{
+				NetworkMirror:    c.String("provider-network-mirror"),
+				FilesystemMirror: c.String("provider-filesystem-mirror"),
+				Direct:           c.Bool("provider-direct"),
+			},
+			Snapshots: state.SnapshotOptions{
+				Store:        c.String("snapshot-store"),
+				Retention:    c.Int("snapshot-retention"),
+				S3Endpoint:   c.String("snapshot-s3-endpoint"),
+				S3Region:     c.String("snapshot-s3-region"),
+				S3Bucket:     c.String("snapshot-s3-bucket"),
+				S3SecretName: c.String("snapshot-s3-secret"),
+			},
+			RecordApprovals: c.String("admission-listen") != "",
+		},
 	)
 
+	if addr := c.String("webhook-listen"); addr != "" {
+		secret := c.String("webhook-secret")
+		if secret == "" {
+			logrus.Fatal("--webhook-listen requires --webhook-secret to validate git push webhooks")
+		}
+		receiver := webhook.NewReceiver(tfFactory.Terraformcontroller().V1().Module(), secret)
+		go func() {
+			if err := webhook.ListenAndServe(ctx, addr, receiver); err != nil {
+				logrus.Fatalf("Error serving webhooks: %s", err.Error())
+			}
+		}()
+	}
+
+	if addr := c.String("admission-listen"); addr != "" {
+		controllerUser := c.String("admission-controller-user")
+		if controllerUser == "" {
+			controllerUser = fmt.Sprintf("system:serviceaccount:%s:terraform-controller", ns)
+		}
+		validator := admission.NewValidator(tfFactory.Terraformcontroller().V1().Execution(),
+			tfFactory.Terraformcontroller().V1().State(), controllerUser)
+
+		var getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+		if certFile := c.String("admission-cert-file"); certFile != "" {
+			cert, err := tls.LoadX509KeyPair(certFile, c.String("admission-key-file"))
+			if err != nil {
+				logrus.Fatalf("Error loading admission certificate: %s", err.Error())
+			}
+			getCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				return &cert, nil
+			}
+		} else {
+			selfSigned := admission.NewSelfSigned(ns, coreFactory.Core().V1().Secret(), k8s.AdmissionregistrationV1())
+			if err := selfSigned.Start(ctx); err != nil {
+				logrus.Fatalf("Error setting up admission certificate: %s", err.Error())
+			}
+			getCertificate = selfSigned.GetCertificate
+		}
+
+		go func() {
+			if err := admission.ListenAndServeTLS(ctx, addr, getCertificate, validator); err != nil {
+				logrus.Fatalf("Error serving admission webhooks: %s", err.Error())
+			}
+		}()
+	}
+
 	if err := start.All(ctx, threadiness, tfFactory, coreFactory, rbacFactory, batchFactory); err != nil {
 		logrus.Fatalf("Error starting: %s", err.Error())
 	}
@@ -0,0 +1,312 @@
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	tfv1 "github.com/rancher/terraform-controller/pkg/generated/controllers/terraformcontroller.cattle.io/v1"
+	"github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	approvedAnnotation = "approved"
+
+	// recordTimeout is how long an approval waits for the request that made it to be stored
+	recordTimeout = 10 * time.Second
+)
+
+// Validator checks the specs of Modules, States and Executions. It also checks who approves
+// Executions and records the approvals, and records who changed States. Only the controller
+// itself writes the records.
+type Validator struct {
+	executions tfv1.ExecutionController
+	states     tfv1.StateController
+	// controllerUser is the user the controller runs as
+	controllerUser string
+	// record runs f once the request was admitted, it is replaced in tests
+	record func(f func() error)
+}
+
+func NewValidator(executions tfv1.ExecutionController, states tfv1.StateController, controllerUser string) *Validator {
+	return &Validator{
+		executions:     executions,
+		states:         states,
+		controllerUser: controllerUser,
+		record: func(f func() error) {
+			go func() {
+				if err := f(); err != nil {
+					logrus.Errorf("Error recording admission: %v", err)
+				}
+			}()
+		},
+	}
+}
+
+// Validate admits the request or returns why it is denied
+func (v *Validator) Validate(req *admissionv1.AdmissionRequest) error {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return nil
+	}
+
+	switch req.Kind.Kind {
+	case "Module":
+		return validateModule(req)
+	case "Execution":
+		if err := validateExecutionSpec(req, v.controllerUser); err != nil {
+			return err
+		}
+		return v.validateApproval(req)
+	case "State":
+		if err := validateState(req); err != nil {
+			return err
+		}
+		return v.validateChange(req)
+	}
+	return nil
+}
+
+func (v *Validator) validateApproval(req *admissionv1.AdmissionRequest) error {
+	execution := &v1.Execution{}
+	if err := json.Unmarshal(req.Object.Raw, execution); err != nil {
+		return errors.Wrap(err, "decoding execution")
+	}
+	old := &v1.Execution{}
+	if req.Operation == admissionv1.Update {
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return errors.Wrap(err, "decoding old execution")
+		}
+	}
+
+	user := req.UserInfo.Username
+	if user != v.controllerUser {
+		if !reflect.DeepEqual(old.Status.Approvals, execution.Status.Approvals) {
+			return errors.New("status.approvals is only written by the controller")
+		}
+	}
+
+	decision := strings.ToLower(strings.TrimSpace(execution.Annotations[approvedAnnotation]))
+	if decision == "" {
+		return nil
+	}
+	oldVersion := old.ResourceVersion
+	if decision == strings.ToLower(strings.TrimSpace(old.Annotations[approvedAnnotation])) {
+		// another approver setting the decision the annotation already holds, e.g. with kubectl
+		// annotate --overwrite, changes nothing but still approves. Nothing is stored, so the
+		// decision is watched for from the current version, which lists the execution.
+		if user == v.controllerUser || !unchanged(old, execution) {
+			return nil
+		}
+		oldVersion = ""
+	}
+	if decision != "yes" && decision != "no" {
+		return fmt.Errorf("invalid value %q for annotation %s, set yes or no", execution.Annotations[approvedAnnotation], approvedAnnotation)
+	}
+
+	if err := v.checkApprover(execution, req.UserInfo.Username, req.UserInfo.Groups); err != nil {
+		return err
+	}
+
+	if !dryRun(req) && !decided(execution.Status.Approvals, user, decision) {
+		approval := v1.Approval{User: user, Decision: decision, Time: metav1.Now()}
+		v.record(func() error {
+			return v.recordApproval(execution.Namespace, execution.Name, oldVersion, approval)
+		})
+	}
+	return nil
+}
+
+// unchanged is true if the update leaves the execution as it was
+func unchanged(old, execution *v1.Execution) bool {
+	return reflect.DeepEqual(old.Labels, execution.Labels) &&
+		reflect.DeepEqual(old.Annotations, execution.Annotations) &&
+		reflect.DeepEqual(old.Finalizers, execution.Finalizers) &&
+		reflect.DeepEqual(old.OwnerReferences, execution.OwnerReferences) &&
+		reflect.DeepEqual(old.Spec, execution.Spec) &&
+		reflect.DeepEqual(old.Status, execution.Status)
+}
+
+// checkApprover returns why user can't approve the execution, nil if they can
+func (v *Validator) checkApprover(execution *v1.Execution, user string, groups []string) error {
+	author := execution.Spec.ChangedBy
+	if execution.Spec.ExecutionName != "" {
+		// the state may have been changed since the execution was created
+		state, err := v.states.Cache().Get(execution.Namespace, execution.Spec.ExecutionName)
+		if err != nil && !k8sError.IsNotFound(err) {
+			return err
+		}
+		if err == nil && state.Status.ChangedBy == user {
+			author = user
+		}
+	}
+	if author != "" && author == user {
+		return fmt.Errorf("%s changed the state and can't approve its plan", user)
+	}
+
+	policy := execution.Spec.Approval
+	if policy == nil || len(policy.Groups) == 0 {
+		return nil
+	}
+	for _, group := range groups {
+		for _, approver := range policy.Groups {
+			if group == approver {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%s is not in any of the approver groups %s", user, strings.Join(policy.Groups, ", "))
+}
+
+// recordApproval appends the approval to the execution's status once the request that made it
+// was stored. Other writes, such as the executor's status updates, change the execution too, so
+// the changes after the request's old version are watched for the decision in the approved
+// annotation. The executor may clear the annotation right after, which a watch doesn't miss.
+func (v *Validator) recordApproval(namespace, name, oldVersion string, approval v1.Approval) error {
+	w, err := v.executions.Watch(namespace, metav1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", name).String(),
+		ResourceVersion: oldVersion,
+	})
+	if err == nil {
+		err = waitForDecision(w, approval.Decision, recordTimeout)
+		w.Stop()
+	}
+	if err != nil {
+		return errors.Wrapf(err, "waiting for the approval of %s/%s by %s to be stored", namespace, name, approval.User)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		execution, err := v.executions.Get(namespace, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if decided(execution.Status.Approvals, approval.User, approval.Decision) {
+			return nil
+		}
+
+		execution = execution.DeepCopy()
+		execution.Status.Approvals = append(execution.Status.Approvals, approval)
+		if _, err := v.executions.Update(execution); err != nil {
+			return err
+		}
+		logrus.Infof("Recorded approval %s of execution %s/%s by %s", approval.Decision, namespace, name, approval.User)
+		return nil
+	})
+}
+
+// waitForDecision waits for a change of the execution that stores the decision in its approved
+// annotation, failing once the execution is deleted or the timeout passed
+func waitForDecision(w watch.Interface, decision string, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			return errors.New("timed out")
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return errors.New("watch closed")
+			}
+			switch event.Type {
+			case watch.Error:
+				return k8sError.FromObject(event.Object)
+			case watch.Deleted:
+				return errors.New("execution was deleted")
+			}
+			execution, ok := event.Object.(*v1.Execution)
+			if ok && strings.ToLower(strings.TrimSpace(execution.Annotations[approvedAnnotation])) == decision {
+				return nil
+			}
+		}
+	}
+}
+
+func (v *Validator) validateChange(req *admissionv1.AdmissionRequest) error {
+	state := &v1.State{}
+	if err := json.Unmarshal(req.Object.Raw, state); err != nil {
+		return errors.Wrap(err, "decoding state")
+	}
+	old := &v1.State{}
+	if req.Operation == admissionv1.Update {
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return errors.Wrap(err, "decoding old state")
+		}
+	}
+
+	user := req.UserInfo.Username
+	if user == v.controllerUser {
+		return nil
+	}
+	if old.Status.ChangedBy != state.Status.ChangedBy {
+		return errors.New("status.changedBy is only written by the controller")
+	}
+
+	if reflect.DeepEqual(old.Spec, state.Spec) &&
+		old.Annotations[v1.StateOperationAnnotation] == state.Annotations[v1.StateOperationAnnotation] {
+		return nil
+	}
+	if !dryRun(req) && state.Status.ChangedBy != user {
+		v.record(func() error {
+			return v.recordChange(state.Namespace, state.Name, old.ResourceVersion, user)
+		})
+	}
+	return nil
+}
+
+// recordChange sets who last changed the state once the change was stored
+func (v *Validator) recordChange(namespace, name, oldVersion, user string) error {
+	err := wait.PollImmediate(250*time.Millisecond, recordTimeout, func() (bool, error) {
+		state, err := v.states.Get(namespace, name, metav1.GetOptions{})
+		if k8sError.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return state.ResourceVersion != oldVersion, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "waiting for the change of state %s/%s by %s to be stored", namespace, name, user)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		state, err := v.states.Get(namespace, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if state.Status.ChangedBy == user {
+			return nil
+		}
+
+		state = state.DeepCopy()
+		state.Status.ChangedBy = user
+		_, err = v.states.Update(state)
+		return err
+	})
+}
+
+// dryRun is true for requests that aren't stored, whose admission mustn't be recorded
+func dryRun(req *admissionv1.AdmissionRequest) bool {
+	return req.DryRun != nil && *req.DryRun
+}
+
+// decided is true if the latest decision of user in approvals is decision
+func decided(approvals []v1.Approval, user, decision string) bool {
+	for i := len(approvals) - 1; i >= 0; i-- {
+		if approvals[i].User == user {
+			return approvals[i].Decision == decision
+		}
+	}
+	return false
+}
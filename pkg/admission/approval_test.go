@@ -0,0 +1,140 @@
+package admission
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const controllerUser = "system:serviceaccount:terraform-controller:terraform-controller"
+
+func updateRequest(t *testing.T, kind, user string, groups []string, old, obj interface{}) *admissionv1.AdmissionRequest {
+	oldRaw, err := json.Marshal(old)
+	require.NoError(t, err)
+	raw, err := json.Marshal(obj)
+	require.NoError(t, err)
+	return &admissionv1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Group: v1.SchemeGroupVersion.Group, Version: "v1", Kind: kind},
+		Operation: admissionv1.Update,
+		UserInfo:  authenticationv1.UserInfo{Username: user, Groups: groups},
+		Object:    runtime.RawExtension{Raw: raw},
+		OldObject: runtime.RawExtension{Raw: oldRaw},
+	}
+}
+
+func testValidator() (*Validator, *int) {
+	recorded := 0
+	return &Validator{
+		controllerUser: controllerUser,
+		record:         func(func() error) { recorded++ },
+	}, &recorded
+}
+
+func TestValidateApproval(t *testing.T) {
+	old := &v1.Execution{
+		ObjectMeta: metav1.ObjectMeta{Name: "vpc-x7k2p", Namespace: "default", ResourceVersion: "10"},
+		Spec: v1.ExecutionSpec{
+			ChangedBy: "alice",
+			Approval:  &v1.ApprovalPolicy{Approvals: 2, Groups: []string{"platform"}},
+		},
+	}
+	approve := func(value string) *v1.Execution {
+		e := old.DeepCopy()
+		e.Annotations = map[string]string{"approved": value}
+		return e
+	}
+
+	v, recorded := testValidator()
+	assert.NoError(t, v.Validate(updateRequest(t, "Execution", "bob", []string{"platform"}, old, approve("yes"))))
+	assert.Equal(t, 1, *recorded)
+
+	assert.EqualError(t, v.Validate(updateRequest(t, "Execution", "alice", []string{"platform"}, old, approve("yes"))),
+		"alice changed the state and can't approve its plan")
+	assert.EqualError(t, v.Validate(updateRequest(t, "Execution", "carol", []string{"dev"}, old, approve("yes"))),
+		"carol is not in any of the approver groups platform")
+	assert.Error(t, v.Validate(updateRequest(t, "Execution", "bob", []string{"platform"}, old, approve("maybe"))))
+
+	// approving again, or clearing the annotation, records nothing
+	already := approve("yes")
+	already.Status.Approvals = []v1.Approval{{User: "bob", Decision: "yes"}}
+	cleared := already.DeepCopy()
+	delete(cleared.Annotations, "approved")
+	assert.NoError(t, v.Validate(updateRequest(t, "Execution", controllerUser, nil, approve("yes"), already)))
+	assert.NoError(t, v.Validate(updateRequest(t, "Execution", "bob", []string{"platform"}, cleared, already)))
+	assert.NoError(t, v.Validate(updateRequest(t, "Execution", "bob", []string{"platform"}, already, cleared)))
+	assert.Equal(t, 1, *recorded)
+
+	// a second approver setting the annotation it already holds is recorded, other edits aren't
+	assert.NoError(t, v.Validate(updateRequest(t, "Execution", "carol", []string{"platform"}, already, already)))
+	assert.Equal(t, 2, *recorded)
+	labeled := already.DeepCopy()
+	labeled.Labels = map[string]string{"team": "platform"}
+	assert.NoError(t, v.Validate(updateRequest(t, "Execution", "carol", []string{"platform"}, already, labeled)))
+	assert.NoError(t, v.Validate(updateRequest(t, "Execution", controllerUser, nil, already, already)))
+	assert.Equal(t, 2, *recorded)
+	assert.EqualError(t, v.Validate(updateRequest(t, "Execution", "dave", []string{"dev"}, already, already)),
+		"dave is not in any of the approver groups platform")
+
+	forged := old.DeepCopy()
+	forged.Status.Approvals = []v1.Approval{{User: "dave", Decision: "yes"}}
+	assert.EqualError(t, v.Validate(updateRequest(t, "Execution", "bob", nil, old, forged)),
+		"status.approvals is only written by the controller")
+
+	lowered := old.DeepCopy()
+	lowered.Spec.Approval.Approvals = 1
+	assert.Error(t, v.Validate(updateRequest(t, "Execution", "bob", nil, old, lowered)))
+}
+
+func TestValidateStateChange(t *testing.T) {
+	old := &v1.State{
+		ObjectMeta: metav1.ObjectMeta{Name: "vpc", Namespace: "default", ResourceVersion: "10"},
+		Spec:       v1.StateSpec{ModuleName: "vpc"},
+	}
+	changed := old.DeepCopy()
+	changed.Spec.AutoConfirm = true
+
+	v, recorded := testValidator()
+	assert.NoError(t, v.Validate(updateRequest(t, "State", "alice", nil, old, changed)))
+	assert.Equal(t, 1, *recorded)
+
+	// status updates and the controller's own changes aren't recorded
+	assert.NoError(t, v.Validate(updateRequest(t, "State", "alice", nil, old, old)))
+	assert.NoError(t, v.Validate(updateRequest(t, "State", controllerUser, nil, old, changed)))
+	assert.Equal(t, 1, *recorded)
+
+	forged := old.DeepCopy()
+	forged.Status.ChangedBy = "bob"
+	assert.EqualError(t, v.Validate(updateRequest(t, "State", "alice", nil, old, forged)),
+		"status.changedBy is only written by the controller")
+}
+
+func TestWaitForDecision(t *testing.T) {
+	execution := func(decision string) *v1.Execution {
+		return &v1.Execution{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{approvedAnnotation: decision}}}
+	}
+
+	w := watch.NewFakeWithChanSize(3, false)
+	// a status write before the request was stored, then the request
+	w.Modify(&v1.Execution{})
+	w.Modify(execution(" Yes"))
+	assert.NoError(t, waitForDecision(w, "yes", time.Second))
+
+	// the request was rejected after this webhook admitted it
+	w = watch.NewFakeWithChanSize(2, false)
+	w.Modify(&v1.Execution{})
+	w.Modify(execution("no"))
+	assert.Error(t, waitForDecision(w, "yes", 50*time.Millisecond))
+
+	w = watch.NewFakeWithChanSize(1, false)
+	w.Delete(&v1.Execution{})
+	assert.Error(t, waitForDecision(w, "yes", time.Second))
+}
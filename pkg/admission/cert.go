@@ -0,0 +1,327 @@
+package admission
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	admissionregistration "k8s.io/client-go/kubernetes/typed/admissionregistration/v1"
+)
+
+const (
+	// WebhookName is the name of the webhook configurations and the service the API server calls
+	WebhookName = "terraform-controller"
+	// CertSecretName is the secret the self-signed certificate is kept in
+	CertSecretName = "terraform-controller-admission-tls"
+	// caKeyKey is the key of the CA's private key in the certificate secret
+	caKeyKey = "ca.key"
+
+	caValidity   = 10 * 365 * 24 * time.Hour
+	certValidity = 365 * 24 * time.Hour
+	// renewBefore is how long before it expires the certificate is replaced
+	renewBefore = 30 * 24 * time.Hour
+)
+
+// SelfSigned keeps a self-signed certificate for the webhook service in a secret, and the CA
+// bundle of the webhook configurations in sync with it
+type SelfSigned struct {
+	namespace string
+	secrets   corecontrollers.SecretClient
+	webhooks  admissionregistration.AdmissionregistrationV1Interface
+
+	lock sync.RWMutex
+	cert *tls.Certificate
+}
+
+func NewSelfSigned(namespace string, secrets corecontrollers.SecretClient,
+	webhooks admissionregistration.AdmissionregistrationV1Interface) *SelfSigned {
+	return &SelfSigned{
+		namespace: namespace,
+		secrets:   secrets,
+		webhooks:  webhooks,
+	}
+}
+
+// Start loads or creates the certificate and registers the webhooks, then checks once a day
+// whether the certificate needs to be renewed until ctx is done
+func (s *SelfSigned) Start(ctx context.Context) error {
+	if err := s.sync(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.sync(ctx); err != nil {
+					logrus.Errorf("Error renewing the admission certificate: %v", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// GetCertificate returns the current certificate, it is used as tls.Config.GetCertificate
+func (s *SelfSigned) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.cert == nil {
+		return nil, errors.New("no admission certificate loaded")
+	}
+	return s.cert, nil
+}
+
+func (s *SelfSigned) sync(ctx context.Context) error {
+	secret, err := s.secrets.Get(s.namespace, CertSecretName, metav1.GetOptions{})
+	if err != nil && !k8sError.IsNotFound(err) {
+		return err
+	}
+	if k8sError.IsNotFound(err) {
+		secret = nil
+	}
+
+	if secret == nil || !validCertificate(secret.Data[corev1.TLSCertKey], time.Now().Add(renewBefore)) {
+		data, err := s.generate(secret)
+		if err != nil {
+			return errors.Wrap(err, "generating the admission certificate")
+		}
+		if secret == nil {
+			secret, err = s.secrets.Create(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: CertSecretName, Namespace: s.namespace},
+				Type:       corev1.SecretTypeTLS,
+				Data:       data,
+			})
+		} else {
+			secret = secret.DeepCopy()
+			secret.Data = data
+			secret, err = s.secrets.Update(secret)
+		}
+		if err != nil {
+			return errors.Wrap(err, "saving the admission certificate")
+		}
+		logrus.Infof("Generated the admission certificate in secret %s/%s", s.namespace, CertSecretName)
+	}
+
+	cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return errors.Wrapf(err, "loading the admission certificate from secret %s/%s", s.namespace, CertSecretName)
+	}
+	s.lock.Lock()
+	s.cert = &cert
+	s.lock.Unlock()
+
+	return s.register(ctx, secret.Data[corev1.ServiceAccountRootCAKey])
+}
+
+// generate returns the data of the certificate secret. The CA in the existing secret is kept
+// while it is valid, so the CA bundle only changes when the CA does.
+func (s *SelfSigned) generate(existing *corev1.Secret) (map[string][]byte, error) {
+	now := time.Now()
+
+	var (
+		ca    *x509.Certificate
+		caKey *ecdsa.PrivateKey
+		caPEM []byte
+	)
+	if existing != nil && validCertificate(existing.Data[corev1.ServiceAccountRootCAKey], now.Add(certValidity)) {
+		caPEM = existing.Data[corev1.ServiceAccountRootCAKey]
+		ca, caKey = parseCA(caPEM, existing.Data[caKeyKey])
+	}
+	if ca == nil {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		template, err := certificateTemplate(now, caValidity)
+		if err != nil {
+			return nil, err
+		}
+		template.Subject = pkix.Name{CommonName: WebhookName + "-ca"}
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			return nil, err
+		}
+		if ca, err = x509.ParseCertificate(der); err != nil {
+			return nil, err
+		}
+		caKey = key
+		caPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template, err := certificateTemplate(now, certValidity)
+	if err != nil {
+		return nil, err
+	}
+	template.Subject = pkix.Name{CommonName: fmt.Sprintf("%s.%s.svc", WebhookName, s.namespace)}
+	template.DNSNames = []string{
+		WebhookName,
+		fmt.Sprintf("%s.%s", WebhookName, s.namespace),
+		fmt.Sprintf("%s.%s.svc", WebhookName, s.namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", WebhookName, s.namespace),
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	caKeyDER, err := x509.MarshalECPrivateKey(caKey)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		corev1.ServiceAccountRootCAKey: caPEM,
+		caKeyKey:                       pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: caKeyDER}),
+		corev1.TLSCertKey:              pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		corev1.TLSPrivateKeyKey:        pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+func certificateTemplate(now time.Time, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	return &x509.Certificate{
+		SerialNumber: serial,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(validity),
+	}, nil
+}
+
+// parseCA returns the CA and its key, nil if they can't be parsed
+func parseCA(certPEM, keyPEM []byte) (*x509.Certificate, *ecdsa.PrivateKey) {
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil
+	}
+	ca, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil
+	}
+	return ca, key
+}
+
+// validCertificate is true if certPEM holds a certificate still valid at the given time
+func validCertificate(certPEM []byte, at time.Time) bool {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	return at.Before(cert.NotAfter)
+}
+
+// register creates or updates the validating and mutating webhook configurations to call the
+// service with caBundle. They are overwritten so upgrades change their rules too.
+func (s *SelfSigned) register(ctx context.Context, caBundle []byte) error {
+	rules := []admissionregistrationv1.RuleWithOperations{{
+		Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+		Rule: admissionregistrationv1.Rule{
+			APIGroups:   []string{"terraformcontroller.cattle.io"},
+			APIVersions: []string{"v1"},
+			Resources:   []string{"modules", "states", "executions"},
+		},
+	}}
+	clientConfig := func(path string) admissionregistrationv1.WebhookClientConfig {
+		return admissionregistrationv1.WebhookClientConfig{
+			Service: &admissionregistrationv1.ServiceReference{
+				Namespace: s.namespace,
+				Name:      WebhookName,
+				Path:      &path,
+			},
+			CABundle: caBundle,
+		}
+	}
+	fail := admissionregistrationv1.Fail
+	noneOnDryRun := admissionregistrationv1.SideEffectClassNoneOnDryRun
+	none := admissionregistrationv1.SideEffectClassNone
+
+	validating := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: WebhookName},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+			Name:                    "validate.terraformcontroller.cattle.io",
+			AdmissionReviewVersions: []string{"v1"},
+			SideEffects:             &noneOnDryRun,
+			FailurePolicy:           &fail,
+			ClientConfig:            clientConfig(ValidatePath),
+			Rules:                   rules,
+		}},
+	}
+	existingValidating, err := s.webhooks.ValidatingWebhookConfigurations().Get(ctx, WebhookName, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		_, err = s.webhooks.ValidatingWebhookConfigurations().Create(ctx, validating, metav1.CreateOptions{})
+	} else if err == nil {
+		existingValidating = existingValidating.DeepCopy()
+		existingValidating.Webhooks = validating.Webhooks
+		_, err = s.webhooks.ValidatingWebhookConfigurations().Update(ctx, existingValidating, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return errors.Wrap(err, "registering the validating webhook")
+	}
+
+	mutating := &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: WebhookName},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{{
+			Name:                    "default.terraformcontroller.cattle.io",
+			AdmissionReviewVersions: []string{"v1"},
+			SideEffects:             &none,
+			FailurePolicy:           &fail,
+			ClientConfig:            clientConfig(MutatePath),
+			Rules:                   rules,
+		}},
+	}
+	existingMutating, err := s.webhooks.MutatingWebhookConfigurations().Get(ctx, WebhookName, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		_, err = s.webhooks.MutatingWebhookConfigurations().Create(ctx, mutating, metav1.CreateOptions{})
+	} else if err == nil {
+		existingMutating = existingMutating.DeepCopy()
+		existingMutating.Webhooks = mutating.Webhooks
+		_, err = s.webhooks.MutatingWebhookConfigurations().Update(ctx, existingMutating, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return errors.Wrap(err, "registering the mutating webhook")
+	}
+	return nil
+}
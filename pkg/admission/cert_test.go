@@ -0,0 +1,39 @@
+package admission
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGenerateCertificate(t *testing.T) {
+	s := &SelfSigned{namespace: "terraform-controller"}
+	data, err := s.generate(nil)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(data[corev1.ServiceAccountRootCAKey]))
+	block, _ := pem.Decode(data[corev1.TLSCertKey])
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	_, err = cert.Verify(x509.VerifyOptions{
+		DNSName: "terraform-controller.terraform-controller.svc",
+		Roots:   roots,
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, validCertificate(data[corev1.TLSCertKey], time.Now().Add(renewBefore)))
+	assert.False(t, validCertificate(data[corev1.TLSCertKey], time.Now().Add(2*certValidity)))
+
+	// renewing keeps the CA, so the webhooks' CA bundle stays valid
+	renewed, err := s.generate(&corev1.Secret{Data: data})
+	require.NoError(t, err)
+	assert.Equal(t, data[corev1.ServiceAccountRootCAKey], renewed[corev1.ServiceAccountRootCAKey])
+	assert.NotEqual(t, data[corev1.TLSCertKey], renewed[corev1.TLSCertKey])
+}
@@ -0,0 +1,66 @@
+package admission
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/pkg/errors"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/terraform/module"
+	"github.com/rancher/terraform-controller/pkg/terraform/state"
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+// patchOperation is an operation of a JSON patch
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Default returns a JSON patch setting the defaults of the object's spec, nil if there are none
+// to set
+func Default(req *admissionv1.AdmissionRequest) ([]byte, error) {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return nil, nil
+	}
+
+	switch req.Kind.Kind {
+	case "Module":
+		obj := &v1.Module{}
+		if err := json.Unmarshal(req.Object.Raw, obj); err != nil {
+			return nil, errors.Wrap(err, "decoding module")
+		}
+		spec := obj.Spec.DeepCopy()
+		module.SetDefaults(obj)
+		return specPatch(spec, &obj.Spec)
+	case "State":
+		obj := &v1.State{}
+		if err := json.Unmarshal(req.Object.Raw, obj); err != nil {
+			return nil, errors.Wrap(err, "decoding state")
+		}
+		spec := obj.Spec.DeepCopy()
+		state.SetDefaults(obj)
+		return specPatch(spec, &obj.Spec)
+	case "Execution":
+		obj := &v1.Execution{}
+		if err := json.Unmarshal(req.Object.Raw, obj); err != nil {
+			return nil, errors.Wrap(err, "decoding execution")
+		}
+		spec := obj.Spec.DeepCopy()
+		if obj.Spec.Approval != nil && obj.Spec.Approval.Approvals < 1 {
+			obj.Spec.Approval.Approvals = 1
+		}
+		return specPatch(spec, &obj.Spec)
+	}
+	return nil, nil
+}
+
+// specPatch replaces the spec when defaults changed it. The add operation replaces existing
+// members, and adds the spec when the object has none.
+func specPatch(old, defaulted interface{}) ([]byte, error) {
+	if reflect.DeepEqual(old, defaulted) {
+		return nil, nil
+	}
+	return json.Marshal([]patchOperation{{Op: "add", Path: "/spec", Value: defaulted}})
+}
@@ -0,0 +1,113 @@
+// Package admission serves the admission webhooks the API server calls before Modules, States
+// and Executions are stored
+package admission
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ValidatePath is the path the validating webhook is served on
+	ValidatePath = "/validate"
+	// MutatePath is the path the defaulting webhook is served on
+	MutatePath = "/mutate"
+
+	maxBodySize = 3 << 20
+)
+
+// admitFunc denies the request by returning an error, or admits it with an optional JSON patch
+type admitFunc func(req *admissionv1.AdmissionRequest) ([]byte, error)
+
+// ListenAndServeTLS runs the webhooks on addr with the certificate getCertificate returns until
+// ctx is done. The API server only calls webhooks over TLS.
+func ListenAndServeTLS(ctx context.Context, addr string, getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error),
+	validator *Validator) error {
+	mux := http.NewServeMux()
+	mux.Handle(ValidatePath, review(func(req *admissionv1.AdmissionRequest) ([]byte, error) {
+		return nil, validator.Validate(req)
+	}))
+	mux.Handle(MutatePath, review(Default))
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig: &tls.Config{
+			GetCertificate: getCertificate,
+			MinVersion:     tls.VersionTLS12,
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	logrus.Infof("Serving admission webhooks on %s", addr)
+	if err := server.ListenAndServeTLS("", ""); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// review decodes the AdmissionReview, and answers it with the request's uid and whether f
+// allowed it
+func review(f admitFunc) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(rw, req.Body, maxBodySize))
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		in := admissionv1.AdmissionReview{}
+		if err := json.Unmarshal(body, &in); err != nil || in.Request == nil {
+			http.Error(rw, "invalid admission review", http.StatusBadRequest)
+			return
+		}
+
+		response := &admissionv1.AdmissionResponse{
+			UID:     in.Request.UID,
+			Allowed: true,
+		}
+		patch, err := f(in.Request)
+		if err != nil {
+			logrus.Infof("Denied %s of %s %s/%s by %s: %v", in.Request.Operation, in.Request.Kind.Kind,
+				in.Request.Namespace, in.Request.Name, in.Request.UserInfo.Username, err)
+			response.Allowed = false
+			response.Result = &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Message: err.Error(),
+				Reason:  metav1.StatusReasonForbidden,
+				Code:    http.StatusForbidden,
+			}
+		} else if patch != nil {
+			patchType := admissionv1.PatchTypeJSONPatch
+			response.Patch = patch
+			response.PatchType = &patchType
+		}
+
+		out := admissionv1.AdmissionReview{
+			TypeMeta: in.TypeMeta,
+			Response: response,
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(rw).Encode(out); err != nil {
+			logrus.Errorf("Error writing admission response: %v", err)
+		}
+	})
+}
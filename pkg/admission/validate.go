@@ -0,0 +1,253 @@
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/executor/cost"
+	"github.com/rancher/terraform-controller/pkg/executor/terraform"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var checksumPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// validateModule checks the module has exactly one source and that the source is complete
+func validateModule(req *admissionv1.AdmissionRequest) error {
+	if !specChanged(req) {
+		return nil
+	}
+
+	module := &v1.Module{}
+	if err := json.Unmarshal(req.Object.Raw, module); err != nil {
+		return errors.Wrap(err, "decoding module")
+	}
+	spec := module.Spec
+
+	var sources []string
+	if len(spec.Content) > 0 {
+		sources = append(sources, "content")
+	}
+	if spec.Git.URL != "" {
+		sources = append(sources, "git")
+	}
+	if spec.Registry != nil {
+		sources = append(sources, "registry")
+	}
+	if spec.HTTP != nil {
+		sources = append(sources, "http")
+	}
+	if spec.OCI != nil {
+		sources = append(sources, "oci")
+	}
+	switch len(sources) {
+	case 0:
+		return errors.New("set one of spec.content, spec.git.url, spec.registry, spec.http or spec.oci")
+	case 1:
+	default:
+		return fmt.Errorf("only one module source can be set, found %s", strings.Join(sources, ", "))
+	}
+
+	git := spec.Git
+	if git.Tag != "" && git.Branch != "" {
+		return errors.New("spec.git.tag and spec.git.branch can't both be set")
+	}
+	if git.Semver != "" {
+		if git.Tag != "" || git.Commit != "" {
+			return errors.New("spec.git.semver selects the tag, it can't be set with spec.git.tag or spec.git.commit")
+		}
+		if _, err := version.NewConstraint(git.Semver); err != nil {
+			return fmt.Errorf("invalid spec.git.semver %q", git.Semver)
+		}
+	}
+	if git.Verification != nil && git.Verification.SecretName == "" {
+		return errors.New("spec.git.verification.secretName is required")
+	}
+	if git.IntervalSeconds < 0 {
+		return errors.New("spec.git.intervalSeconds can't be negative")
+	}
+
+	if r := spec.Registry; r != nil {
+		if r.Namespace == "" || r.Name == "" || r.Provider == "" {
+			return errors.New("spec.registry.namespace, name and provider are required")
+		}
+		if r.Version != "" {
+			if _, err := version.NewConstraint(r.Version); err != nil {
+				return fmt.Errorf("invalid spec.registry.version %q", r.Version)
+			}
+		}
+		if r.IntervalSeconds < 0 {
+			return errors.New("spec.registry.intervalSeconds can't be negative")
+		}
+	}
+
+	if h := spec.HTTP; h != nil {
+		if h.URL == "" {
+			return errors.New("spec.http.url is required")
+		}
+		if !checksumPattern.MatchString(h.Checksum) {
+			return errors.New(`spec.http.checksum is required as "sha256:<hex>"`)
+		}
+	}
+
+	if o := spec.OCI; o != nil {
+		if o.Reference == "" {
+			return errors.New("spec.oci.reference is required")
+		}
+		if o.Digest != "" && !checksumPattern.MatchString(o.Digest) {
+			return fmt.Errorf(`invalid spec.oci.digest %q, set it as "sha256:<hex>"`, o.Digest)
+		}
+		if o.IntervalSeconds < 0 {
+			return errors.New("spec.oci.intervalSeconds can't be negative")
+		}
+	}
+	return nil
+}
+
+// validateState checks the fields of the state's spec the handler would otherwise only reject
+// when it reconciles the state
+func validateState(req *admissionv1.AdmissionRequest) error {
+	if !specChanged(req) {
+		return nil
+	}
+
+	state := &v1.State{}
+	if err := json.Unmarshal(req.Object.Raw, state); err != nil {
+		return errors.Wrap(err, "decoding state")
+	}
+	spec := state.Spec
+
+	if spec.ModuleName == "" {
+		return errors.New("spec.moduleName is required")
+	}
+	if spec.Version < 0 {
+		return errors.New("spec.version can't be negative")
+	}
+	if spec.TerraformVersion != "" {
+		if _, err := version.NewVersion(spec.TerraformVersion); err != nil {
+			return fmt.Errorf("invalid spec.terraformVersion %q", spec.TerraformVersion)
+		}
+	}
+	if _, err := terraform.GetEngine(spec.Engine); err != nil {
+		return errors.Wrap(err, "spec.engine")
+	}
+	if spec.Workspace != "" {
+		if errs := validation.IsDNS1123Label(spec.Workspace); len(errs) > 0 {
+			return fmt.Errorf("invalid spec.workspace %q: %s", spec.Workspace, strings.Join(errs, ", "))
+		}
+	}
+
+	if c := spec.CostEstimation; c != nil {
+		switch c.Estimator {
+		case cost.EstimatorInfracost:
+		case cost.EstimatorPricing:
+			if c.PricingConfigName == "" {
+				return errors.New("spec.costEstimation.pricingConfigName is required by the pricing estimator")
+			}
+		default:
+			return fmt.Errorf("spec.costEstimation.estimator must be %s or %s", cost.EstimatorInfracost, cost.EstimatorPricing)
+		}
+	}
+	if spec.MaxMonthlyCostDelta != "" {
+		if delta, err := strconv.ParseFloat(spec.MaxMonthlyCostDelta, 64); err != nil || delta < 0 {
+			return fmt.Errorf("invalid spec.maxMonthlyCostDelta %q, set a positive number", spec.MaxMonthlyCostDelta)
+		}
+	}
+	if spec.PolicySelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(spec.PolicySelector); err != nil {
+			return errors.Wrap(err, "invalid spec.policySelector")
+		}
+	}
+	for _, pattern := range spec.ProtectedResourceTypes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid spec.protectedResourceTypes pattern %q", pattern)
+		}
+	}
+	if spec.Approval != nil && spec.Approval.Approvals < 0 {
+		return errors.New("spec.approval.approvals can't be negative")
+	}
+	return nil
+}
+
+// validateExecutionSpec keeps the fields that decide what an execution runs, and who approves
+// it, as they were created. The controller sets secretName once the execution has a name.
+func validateExecutionSpec(req *admissionv1.AdmissionRequest, controllerUser string) error {
+	if req.Operation != admissionv1.Update {
+		return nil
+	}
+
+	execution := &v1.Execution{}
+	if err := json.Unmarshal(req.Object.Raw, execution); err != nil {
+		return errors.Wrap(err, "decoding execution")
+	}
+	old := &v1.Execution{}
+	if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+		return errors.Wrap(err, "decoding old execution")
+	}
+
+	fields := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"executionName", old.Spec.ExecutionName, execution.Spec.ExecutionName},
+		{"autoConfirm", old.Spec.AutoConfirm, execution.Spec.AutoConfirm},
+		{"content", old.Spec.Content, execution.Spec.Content},
+		{"contentHash", old.Spec.ContentHash, execution.Spec.ContentHash},
+		{"runHash", old.Spec.RunHash, execution.Spec.RunHash},
+		{"data", old.Spec.Data, execution.Spec.Data},
+		{"secretName", old.Spec.SecretName, execution.Spec.SecretName},
+		{"terraformVersion", old.Spec.TerraformVersion, execution.Spec.TerraformVersion},
+		{"engine", old.Spec.Engine, execution.Spec.Engine},
+		{"providers", old.Spec.Providers, execution.Spec.Providers},
+		{"workspace", old.Spec.Workspace, execution.Spec.Workspace},
+		{"operation", old.Spec.Operation, execution.Spec.Operation},
+		{"costEstimation", old.Spec.CostEstimation, execution.Spec.CostEstimation},
+		{"maxMonthlyCostDelta", old.Spec.MaxMonthlyCostDelta, execution.Spec.MaxMonthlyCostDelta},
+		{"policySelector", old.Spec.PolicySelector, execution.Spec.PolicySelector},
+		{"requireApprovalOnDestroy", old.Spec.RequireApprovalOnDestroy, execution.Spec.RequireApprovalOnDestroy},
+		{"protectedResourceTypes", old.Spec.ProtectedResourceTypes, execution.Spec.ProtectedResourceTypes},
+		{"approval", old.Spec.Approval, execution.Spec.Approval},
+		{"changedBy", old.Spec.ChangedBy, execution.Spec.ChangedBy},
+	}
+	secretNamed := req.UserInfo.Username == controllerUser && old.Spec.SecretName == "" &&
+		execution.Spec.SecretName == "s-"+execution.Name
+	var changed []string
+	for _, f := range fields {
+		if f.name == "secretName" && secretNamed {
+			continue
+		}
+		if !reflect.DeepEqual(f.old, f.new) {
+			changed = append(changed, "spec."+f.name)
+		}
+	}
+	if len(changed) > 0 {
+		return fmt.Errorf("%s of an execution can't be changed", strings.Join(changed, ", "))
+	}
+	return nil
+}
+
+// specChanged is true when the request creates the object or changes its spec. Objects stored
+// before the webhook was installed are only checked once their spec changes, so status updates
+// of the controller aren't denied.
+func specChanged(req *admissionv1.AdmissionRequest) bool {
+	if req.Operation != admissionv1.Update {
+		return true
+	}
+
+	var obj, old struct {
+		Spec interface{} `json:"spec"`
+	}
+	if json.Unmarshal(req.Object.Raw, &obj) != nil || json.Unmarshal(req.OldObject.Raw, &old) != nil {
+		return true
+	}
+	return !reflect.DeepEqual(obj.Spec, old.Spec)
+}
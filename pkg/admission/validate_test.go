@@ -0,0 +1,149 @@
+package admission
+
+import (
+	"encoding/json"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func createRequest(t *testing.T, kind string, obj interface{}) *admissionv1.AdmissionRequest {
+	raw, err := json.Marshal(obj)
+	require.NoError(t, err)
+	return &admissionv1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Group: v1.SchemeGroupVersion.Group, Version: "v1", Kind: kind},
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+}
+
+func TestValidateModule(t *testing.T) {
+	module := func(content v1.ModuleContent) *v1.Module {
+		return &v1.Module{Spec: v1.ModuleSpec{ModuleContent: content}}
+	}
+	tests := []struct {
+		name    string
+		content v1.ModuleContent
+		err     string
+	}{
+		{name: "git", content: v1.ModuleContent{Git: v1.GitLocation{URL: "https://github.com/org/vpc", Branch: "main"}}},
+		{name: "no source", err: "set one of spec.content, spec.git.url, spec.registry, spec.http or spec.oci"},
+		{
+			name: "two sources",
+			content: v1.ModuleContent{
+				Content: map[string]string{"main.tf": ""},
+				Git:     v1.GitLocation{URL: "https://github.com/org/vpc"},
+			},
+			err: "only one module source can be set, found content, git",
+		},
+		{
+			name:    "tag and branch",
+			content: v1.ModuleContent{Git: v1.GitLocation{URL: "https://github.com/org/vpc", Tag: "v1.0.0", Branch: "main"}},
+			err:     "spec.git.tag and spec.git.branch can't both be set",
+		},
+		{
+			name:    "bad semver",
+			content: v1.ModuleContent{Git: v1.GitLocation{URL: "https://github.com/org/vpc", Semver: "one"}},
+			err:     `invalid spec.git.semver "one"`,
+		},
+		{
+			name:    "negative interval",
+			content: v1.ModuleContent{Git: v1.GitLocation{URL: "https://github.com/org/vpc", IntervalSeconds: -1}},
+			err:     "spec.git.intervalSeconds can't be negative",
+		},
+		{
+			name:    "http without checksum",
+			content: v1.ModuleContent{HTTP: &v1.HTTPLocation{URL: "https://example.com/vpc.tar.gz"}},
+			err:     `spec.http.checksum is required as "sha256:<hex>"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateModule(createRequest(t, "Module", module(tt.content)))
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidateState(t *testing.T) {
+	assert.NoError(t, validateState(createRequest(t, "State", &v1.State{Spec: v1.StateSpec{ModuleName: "vpc"}})))
+	assert.EqualError(t, validateState(createRequest(t, "State", &v1.State{})), "spec.moduleName is required")
+
+	// invalid states stored before the webhook still get status updates
+	stored := &v1.State{}
+	updated := stored.DeepCopy()
+	updated.Status.ExecutionName = "vpc-x7k2p"
+	assert.NoError(t, validateState(updateRequest(t, "State", controllerUser, nil, stored, updated)))
+
+	state := &v1.State{Spec: v1.StateSpec{ModuleName: "vpc", Workspace: "Prod_1"}}
+	assert.Error(t, validateState(createRequest(t, "State", state)))
+
+	state = &v1.State{Spec: v1.StateSpec{ModuleName: "vpc", MaxMonthlyCostDelta: "-5"}}
+	assert.EqualError(t, validateState(createRequest(t, "State", state)),
+		`invalid spec.maxMonthlyCostDelta "-5", set a positive number`)
+}
+
+func TestValidateExecutionSpec(t *testing.T) {
+	old := &v1.Execution{
+		ObjectMeta: metav1.ObjectMeta{Name: "vpc-x7k2p", Namespace: "default"},
+		Spec:       v1.ExecutionSpec{ExecutionName: "vpc", ContentHash: "abc", Workspace: "prod"},
+	}
+
+	annotated := old.DeepCopy()
+	annotated.Annotations = map[string]string{"approved": "yes"}
+	annotated.Status.Outputs = "{}"
+	assert.NoError(t, validateExecutionSpec(updateRequest(t, "Execution", "bob", nil, old, annotated), controllerUser))
+
+	changed := old.DeepCopy()
+	changed.Spec.ContentHash = "def"
+	changed.Spec.Workspace = "dev"
+	assert.EqualError(t, validateExecutionSpec(updateRequest(t, "Execution", "bob", nil, old, changed), controllerUser),
+		"spec.contentHash, spec.workspace of an execution can't be changed")
+
+	// the update createExecution makes once the execution is created
+	named := old.DeepCopy()
+	named.Spec.SecretName = "s-" + named.Name
+	assert.NoError(t, validateExecutionSpec(updateRequest(t, "Execution", controllerUser, nil, old, named), controllerUser))
+	assert.EqualError(t, validateExecutionSpec(updateRequest(t, "Execution", "bob", nil, old, named), controllerUser),
+		"spec.secretName of an execution can't be changed")
+
+	renamed := named.DeepCopy()
+	renamed.Spec.SecretName = "s-other"
+	assert.Error(t, validateExecutionSpec(updateRequest(t, "Execution", controllerUser, nil, named, renamed), controllerUser))
+}
+
+func TestDefault(t *testing.T) {
+	state := &v1.State{
+		ObjectMeta: metav1.ObjectMeta{Name: "vpc", Namespace: "default"},
+		Spec:       v1.StateSpec{ModuleName: "vpc", Approval: &v1.ApprovalPolicy{}},
+	}
+	req := createRequest(t, "State", state)
+	patch, err := Default(req)
+	require.NoError(t, err)
+
+	decoded, err := jsonpatch.DecodePatch(patch)
+	require.NoError(t, err)
+	raw, err := decoded.Apply(req.Object.Raw)
+	require.NoError(t, err)
+	defaulted := &v1.State{}
+	require.NoError(t, json.Unmarshal(raw, defaulted))
+	assert.Equal(t, "vpc", defaulted.Spec.ModuleName)
+	assert.Equal(t, int32(1), defaulted.Spec.Version)
+	assert.Equal(t, "rancher/terraform-controller-executor:latest", defaulted.Spec.Image)
+	assert.Equal(t, 1, defaulted.Spec.Approval.Approvals)
+
+	// nothing left to default
+	patch, err = Default(createRequest(t, "State", defaulted))
+	require.NoError(t, err)
+	assert.Nil(t, patch)
+}
@@ -7,17 +7,37 @@ import (
 )
 
 var (
-	ModuleConditionGitUpdated = condition.Cond("GitUpdated")
+	ModuleConditionGitUpdated      = condition.Cond("GitUpdated")
+	ModuleConditionRegistryUpdated = condition.Cond("RegistryUpdated")
+	ModuleConditionOCIUpdated      = condition.Cond("OCIUpdated")
+	ModuleConditionVerified        = condition.Cond("Verified")
+	ModuleConditionValid           = condition.Cond("Valid")
 
 	StateConditionJobDeployed      = condition.Cond("JobDeployed")
 	ExecutionConditionMissingInfo  = condition.Cond("MissingInfo")
 	ExecutionConditionWatchRunning = condition.Cond("WatchRunning")
 	StateConditionDestroyed        = condition.Cond("Destroyed")
+	StateConditionTerraformVersion = condition.Cond("TerraformVersion")
 
-	ExecutionRunConditionPlanned = condition.Cond("Planned")
-	ExecutionRunConditionApplied = condition.Cond("Applied")
+	ExecutionRunConditionPlanned          = condition.Cond("Planned")
+	ExecutionRunConditionAwaitingApproval = condition.Cond("AwaitingApproval")
+	ExecutionRunConditionApplied          = condition.Cond("Applied")
+	ExecutionRunConditionFailed           = condition.Cond("Failed")
 )
 
+const (
+	// ModulePhasePending is a module whose source has not been resolved yet
+	ModulePhasePending = "Pending"
+	// ModulePhaseReady is a module with resolved content
+	ModulePhaseReady = "Ready"
+	// ModulePhaseFailed is a module whose last check failed, see status.lastError
+	ModulePhaseFailed = "Failed"
+)
+
+// StateOperationAnnotation requests a one-shot run of a State, its value is a JSON encoded
+// Operation. The controller removes it once the execution has been created.
+const StateOperationAnnotation = "terraformcontroller.cattle.io/operation"
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -34,16 +54,53 @@ type ModuleSpec struct {
 }
 
 type ModuleContent struct {
-	Content map[string]string `json:"content,omitempty"`
-	Git     GitLocation       `json:"git,omitempty"`
+	Content  map[string]string `json:"content,omitempty"`
+	Git      GitLocation       `json:"git,omitempty"`
+	Registry *RegistryLocation `json:"registry,omitempty"`
+	HTTP     *HTTPLocation     `json:"http,omitempty"`
+	OCI      *OCILocation      `json:"oci,omitempty"`
 }
 
 type ModuleStatus struct {
-	CheckTime   metav1.Time                         `json:"time,omitempty"`
-	GitChecked  *GitLocation                        `json:"gitChecked,omitempty"`
-	Content     ModuleContent                       `json:"content,omitempty"`
-	ContentHash string                              `json:"contentHash,omitempty"`
-	Conditions  []genericcondition.GenericCondition `json:"conditions,omitempty"`
+	CheckTime       metav1.Time                         `json:"time,omitempty"`
+	GitChecked      *GitLocation                        `json:"gitChecked,omitempty"`
+	RegistryChecked *RegistryLocation                   `json:"registryChecked,omitempty"`
+	OCIChecked      *OCILocation                        `json:"ociChecked,omitempty"`
+	Content         ModuleContent                       `json:"content,omitempty"`
+	ContentHash     string                              `json:"contentHash,omitempty"`
+	Conditions      []genericcondition.GenericCondition `json:"conditions,omitempty"`
+	// Phase is Pending, Ready or Failed
+	Phase     string `json:"phase,omitempty"`
+	LastError string `json:"lastError,omitempty"`
+	// RejectedCommit is the last commit that failed verification, it isn't verified again
+	RejectedCommit string `json:"rejectedCommit,omitempty"`
+	// LastSuccessfulCheck is the last time the module source was resolved without error
+	LastSuccessfulCheck metav1.Time `json:"lastSuccessfulCheck,omitempty"`
+	ObservedGeneration  int64       `json:"observedGeneration,omitempty"`
+	// ValidatedHash is the content hash the Valid condition, Variables and Outputs are for
+	ValidatedHash string           `json:"validatedHash,omitempty"`
+	Variables     []ModuleVariable `json:"variables,omitempty"`
+	Outputs       []ModuleOutput   `json:"outputs,omitempty"`
+	// Unformatted lists the files that do not pass terraform fmt -check
+	Unformatted []string `json:"unformatted,omitempty"`
+	// RequiredVersions are the required_version constraints of the module's terraform blocks
+	RequiredVersions []string `json:"requiredVersions,omitempty"`
+}
+
+// ModuleVariable is an input variable declared by the module content
+type ModuleVariable struct {
+	Name        string `json:"name,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Sensitive   bool   `json:"sensitive,omitempty"`
+}
+
+// ModuleOutput is an output declared by the module content
+type ModuleOutput struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Sensitive   bool   `json:"sensitive,omitempty"`
 }
 
 type GitLocation struct {
@@ -53,6 +110,55 @@ type GitLocation struct {
 	Commit          string `json:"commit,omitempty"`
 	SecretName      string `json:"secretName,omitempty"`
 	IntervalSeconds int    `json:"intervalSeconds,omitempty"`
+	// Semver tracks the highest tag matching the constraint, e.g. "~1.4" or ">=2.0.0 <3"
+	Semver string `json:"semver,omitempty"`
+	// Verification requires commits to be signed by a trusted key before they are used
+	Verification *GitVerification `json:"verification,omitempty"`
+}
+
+// RegistryLocation is a module published to a terraform module registry
+type RegistryLocation struct {
+	// Host of the registry, defaults to registry.terraform.io
+	Host      string `json:"host,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+	// Version constraint in terraform syntax, e.g. "~> 1.2", empty tracks the latest release
+	Version string `json:"version,omitempty"`
+	// SecretName of a secret with a "token" key for private registries
+	SecretName      string `json:"secretName,omitempty"`
+	IntervalSeconds int    `json:"intervalSeconds,omitempty"`
+	// ResolvedVersion and Source are set by the controller to the version selected for
+	// the constraint and the address it is downloaded from
+	ResolvedVersion string `json:"resolvedVersion,omitempty"`
+	Source          string `json:"source,omitempty"`
+}
+
+// HTTPLocation is a module packaged as a tar.gz, tar or zip archive
+type HTTPLocation struct {
+	// URL of the archive, a "//dir" suffix selects a subdirectory of the archive
+	URL string `json:"url,omitempty"`
+	// Checksum of the archive as "sha256:<hex>"
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// OCILocation is a module pushed to an OCI registry as an artifact, layers are either
+// tar(.gz) archives of the module or single files named by their title annotation
+type OCILocation struct {
+	// Reference to the repository without tag or digest, e.g. ghcr.io/org/modules/vpc
+	Reference string `json:"reference,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+	// Digest pins the artifact, when only a tag is set the controller resolves it to a digest
+	Digest string `json:"digest,omitempty"`
+	// SecretName of a kubernetes.io/dockerconfigjson pull secret
+	SecretName      string `json:"secretName,omitempty"`
+	PlainHTTP       bool   `json:"plainHTTP,omitempty"`
+	IntervalSeconds int    `json:"intervalSeconds,omitempty"`
+}
+
+type GitVerification struct {
+	// SecretName of a secret holding trusted GPG public keys and/or SSH public keys
+	SecretName string `json:"secretName,omitempty"`
 }
 
 // +genclient
@@ -84,6 +190,74 @@ type StateSpec struct {
 	DestroyOnDelete bool              `json:"destroyOnDelete,omitempty"`
 	Version         int32             `json:"version,omitempty"`
 	NodeSelector    map[string]string `json:"nodeSelector,omitempty"`
+	// TerraformVersion is the terraform release the executor downloads and runs, e.g. 1.0.11.
+	// When empty the terraform binary in the executor image is used.
+	TerraformVersion string `json:"terraformVersion,omitempty"`
+	// Engine is the binary terraform is run with, terraform (default) or tofu
+	Engine string `json:"engine,omitempty"`
+	// Providers overrides the controller's provider installation settings
+	Providers *ProviderInstallation `json:"providers,omitempty"`
+	// Workspace is the terraform workspace the module is run in, created when missing
+	Workspace string `json:"workspace,omitempty"`
+	// CostEstimation estimates the monthly cost change of each plan
+	CostEstimation *CostEstimation `json:"costEstimation,omitempty"`
+	// MaxMonthlyCostDelta is the largest increase of the monthly cost, e.g. "100", applied without
+	// approval when AutoConfirm is set. Plans costing more wait for the approved annotation.
+	MaxMonthlyCostDelta string `json:"maxMonthlyCostDelta,omitempty"`
+	// PolicySelector selects the Policies in the state's namespace every plan is checked against
+	PolicySelector *metav1.LabelSelector `json:"policySelector,omitempty"`
+	// RequireApprovalOnDestroy makes plans deleting or replacing any resource wait for the
+	// approved annotation even when AutoConfirm is set
+	RequireApprovalOnDestroy bool `json:"requireApprovalOnDestroy,omitempty"`
+	// ProtectedResourceTypes are resource types, or glob patterns like aws_db_*, whose deletion
+	// or replacement waits for the approved annotation even when AutoConfirm is set
+	ProtectedResourceTypes []string `json:"protectedResourceTypes,omitempty"`
+	// Approval sets who approves plans and how many approvals they need, enforced by the
+	// controller's admission webhook
+	Approval *ApprovalPolicy `json:"approval,omitempty"`
+}
+
+type ApprovalPolicy struct {
+	// Approvals is the number of users that approve a plan before it's applied, defaults to 1
+	Approvals int `json:"approvals,omitempty"`
+	// Groups are the groups approvers belong to one of, any user allowed to annotate the
+	// execution approves when empty
+	Groups []string `json:"groups,omitempty"`
+}
+
+// CostEstimation selects how the cost of a plan is estimated
+type CostEstimation struct {
+	// Estimator is "infracost" to run an Infracost compatible CLI on the plan, or "pricing" to
+	// price the resource changes with the PricingConfigName config map
+	Estimator string `json:"estimator"`
+	// Command is the Infracost compatible CLI, defaults to infracost in the executor image
+	Command string `json:"command,omitempty"`
+	// PricingConfigName is a config map with a "pricing" key holding a JSON object of resource
+	// types and their monthly cost, e.g. {"aws_instance": 30.5}
+	PricingConfigName string `json:"pricingConfigName,omitempty"`
+	// Currency of the pricing config, defaults to USD
+	Currency string `json:"currency,omitempty"`
+}
+
+// CostEstimate is the estimated monthly cost before and after a plan
+type CostEstimate struct {
+	Currency             string `json:"currency,omitempty"`
+	PastTotalMonthlyCost string `json:"pastTotalMonthlyCost,omitempty"`
+	TotalMonthlyCost     string `json:"totalMonthlyCost,omitempty"`
+	DiffTotalMonthlyCost string `json:"diffTotalMonthlyCost,omitempty"`
+}
+
+// ProviderInstallation configures where terraform init installs providers from
+type ProviderInstallation struct {
+	// NetworkMirror is the url of a provider network mirror
+	NetworkMirror string `json:"networkMirror,omitempty"`
+	// FilesystemMirror is a directory in the executor image holding providers
+	FilesystemMirror string `json:"filesystemMirror,omitempty"`
+	// Direct installs providers missing from the mirrors from their origin registries
+	Direct bool `json:"direct,omitempty"`
+	// LockFileReadonly fails init when the providers don't match the dependency lock file
+	// instead of updating it
+	LockFileReadonly bool `json:"lockFileReadonly,omitempty"`
 }
 
 type StateStatus struct {
@@ -91,6 +265,42 @@ type StateStatus struct {
 	LastRunHash   string                              `json:"lastRunHash,omitempty"`
 	ExecutionName string                              `json:"executionName,omitempty"`
 	StatePlanName string                              `json:"executionPlanName,omitempty"`
+	// MissingVariables are required module variables that none of the variables sources set
+	MissingVariables []string `json:"missingVariables,omitempty"`
+	// UnknownVariables are keys of the variables sources the module does not declare
+	UnknownVariables []string `json:"unknownVariables,omitempty"`
+	// Lock is the backend lock currently held on the state
+	Lock *StateLock `json:"lock,omitempty"`
+	// Snapshots are copies of the terraform state taken after each apply, oldest first
+	Snapshots []StateSnapshot `json:"snapshots,omitempty"`
+	// ChangedBy is the user who last changed the spec or requested an operation, recorded by
+	// the admission webhook
+	ChangedBy string `json:"changedBy,omitempty"`
+}
+
+// StateSnapshot is a copy of the terraform state saved in the snapshot store
+type StateSnapshot struct {
+	// Name is the secret or object key the snapshot is stored as
+	Name      string `json:"name"`
+	Store     string `json:"store"`
+	Serial    int64  `json:"serial"`
+	Lineage   string `json:"lineage,omitempty"`
+	Execution string `json:"execution,omitempty"`
+	// Workspace is the terraform workspace the snapshot was taken of
+	Workspace string      `json:"workspace,omitempty"`
+	Created   metav1.Time `json:"created,omitempty"`
+}
+
+// StateLock is the lock info terraform records in the kubernetes backend's lease
+type StateLock struct {
+	ID        string      `json:"id"`
+	Operation string      `json:"operation,omitempty"`
+	Who       string      `json:"who,omitempty"`
+	Created   metav1.Time `json:"created,omitempty"`
+	// Job is the executor job holding the lock, empty if it was taken outside of an executor
+	Job string `json:"job,omitempty"`
+	// Stale is set when the job holding the lock no longer exists
+	Stale bool `json:"stale,omitempty"`
 }
 
 // +genclient
@@ -113,7 +323,58 @@ type ExecutionSpec struct {
 	ExecutionName    string            `json:"executionName,omitempty"`
 	ExecutionVersion int32             `json:"executionVersion,omitempty"`
 	// Secrets and config maps referenced in the Execution spec will be combined into this secret
-	SecretName string `json:"secretName,omitempty"`
+	SecretName       string `json:"secretName,omitempty"`
+	TerraformVersion string `json:"terraformVersion,omitempty"`
+	Engine           string `json:"engine,omitempty"`
+	// Providers are the provider installation settings of the state or controller
+	Providers *ProviderInstallation `json:"providers,omitempty"`
+	Workspace string                `json:"workspace,omitempty"`
+	// Operation is the one-shot operation this execution was created for
+	Operation           *Operation            `json:"operation,omitempty"`
+	CostEstimation      *CostEstimation       `json:"costEstimation,omitempty"`
+	MaxMonthlyCostDelta string                `json:"maxMonthlyCostDelta,omitempty"`
+	PolicySelector      *metav1.LabelSelector `json:"policySelector,omitempty"`
+	// RequireApprovalOnDestroy and ProtectedResourceTypes are copied from the State
+	RequireApprovalOnDestroy bool            `json:"requireApprovalOnDestroy,omitempty"`
+	ProtectedResourceTypes   []string        `json:"protectedResourceTypes,omitempty"`
+	Approval                 *ApprovalPolicy `json:"approval,omitempty"`
+	// ChangedBy is the user who changed the state when the execution was created, who can't
+	// approve its plan
+	ChangedBy string `json:"changedBy,omitempty"`
+}
+
+// Operation is a one-shot run of a State, either a plan limited or extended by targets and
+// replace addresses, or changes to the terraform state that run without a plan
+type Operation struct {
+	// Action is "plan" to only plan, without taking the state's lock, "refresh" to only
+	// refresh the state or "force-unlock" to release the lock LockID. Empty plans and applies.
+	Action string `json:"action,omitempty"`
+	// LockID is the id of the backend lock a force-unlock releases
+	LockID string `json:"lockID,omitempty"`
+	// Targets are resource addresses the plan is limited to, passed as -target
+	Targets []string `json:"targets,omitempty"`
+	// Replace are resource addresses that are replaced even if unchanged, passed as -replace
+	Replace []string `json:"replace,omitempty"`
+	// Imports adopt existing infrastructure with 'terraform import'
+	Imports []ImportOperation `json:"imports,omitempty"`
+	// Moves rename resources in the state with 'terraform state mv'
+	Moves []MoveOperation `json:"moves,omitempty"`
+	// Removes are resource addresses dropped from the state with 'terraform state rm',
+	// the infrastructure itself is left as is
+	Removes []string `json:"removes,omitempty"`
+	// Restore is the name of a snapshot pushed back as the state with 'terraform state push'
+	Restore string `json:"restore,omitempty"`
+}
+
+type ImportOperation struct {
+	Address string `json:"address"`
+	// ID is the provider specific id of the existing resource
+	ID string `json:"id"`
+}
+
+type MoveOperation struct {
+	From string `json:"from"`
+	To   string `json:"to"`
 }
 
 type ExecutionStatus struct {
@@ -124,4 +385,101 @@ type ExecutionStatus struct {
 	PlanConfirmed bool                                `json:"planConfirmed,omitempty"`
 	ApplyOutput   string                              `json:"applyOutput,omitempty"`
 	Outputs       string                              `json:"outputs,omitempty"`
+	// Cost is the estimated monthly cost change of the plan
+	Cost *CostEstimate `json:"cost,omitempty"`
+	// PolicyViolations are the deny and warn rules of the selected Policies the plan broke
+	PolicyViolations []PolicyViolation `json:"policyViolations,omitempty"`
+	// Approvals are the decisions on the plan recorded by the admission webhook, oldest first
+	Approvals []Approval `json:"approvals,omitempty"`
+	// PlanSummary is the summary line of the plan, e.g. "Plan: 1 to add, 0 to change, 0 to destroy."
+	PlanSummary string `json:"planSummary,omitempty"`
+	// Notified are the events of the execution notifications were sent for
+	Notified []string `json:"notified,omitempty"`
+}
+
+type Approval struct {
+	User string `json:"user"`
+	// Decision is the value of the approved annotation the user set, yes or no
+	Decision string      `json:"decision"`
+	Time     metav1.Time `json:"time"`
+}
+
+type PolicyViolation struct {
+	Policy string `json:"policy"`
+	// Level is "deny", which blocks the apply, or "warn"
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Policy holds Rego modules the plans of the States selecting it are checked against
+type Policy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PolicySpec `json:"spec"`
+}
+
+type PolicySpec struct {
+	// Modules are Rego modules by file name
+	Modules map[string]string `json:"modules,omitempty"`
+	// ConfigMapNames are config maps in the policy's namespace whose keys are Rego modules
+	ConfigMapNames []string `json:"configMapNames,omitempty"`
+	// Package is the Rego package whose deny and warn rules, sets of messages, are evaluated
+	// with the 'terraform show -json' plan as input. Defaults to terraform.
+	Package string `json:"package,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NotificationConfig sends notifications about the executions of States in its namespace
+type NotificationConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NotificationConfigSpec `json:"spec"`
+}
+
+type NotificationConfigSpec struct {
+	// StateSelector selects the States whose executions are notified about, all States of the
+	// namespace when empty
+	StateSelector *metav1.LabelSelector `json:"stateSelector,omitempty"`
+	// Events are planned, awaitingApproval, applied or failed, all of them when empty
+	Events []string `json:"events,omitempty"`
+	// Template is a Go text/template of the message, a summary of the event when empty
+	Template string             `json:"template,omitempty"`
+	Sinks    []NotificationSink `json:"sinks"`
+}
+
+// NotificationSink is where notifications are sent, one of Webhook, Slack or SMTP is set
+type NotificationSink struct {
+	// Name identifies the sink in logs
+	Name string `json:"name,omitempty"`
+	// Webhook posts the notification and its message as JSON
+	Webhook *WebhookSink `json:"webhook,omitempty"`
+	// Slack posts the message to a Slack-compatible incoming webhook
+	Slack *WebhookSink `json:"slack,omitempty"`
+	SMTP  *SMTPSink    `json:"smtp,omitempty"`
+	// Template overrides the template of the config for this sink
+	Template string `json:"template,omitempty"`
+}
+
+type WebhookSink struct {
+	URL string `json:"url,omitempty"`
+	// SecretName of a secret with a "url" key used instead of URL, as webhook URLs often hold
+	// tokens
+	SecretName string `json:"secretName,omitempty"`
+}
+
+type SMTPSink struct {
+	Host string `json:"host"`
+	// Port defaults to 587
+	Port int      `json:"port,omitempty"`
+	From string   `json:"from"`
+	To   []string `json:"to"`
+	// SecretName of a secret with "username" and "password" keys to authenticate with
+	SecretName string `json:"secretName,omitempty"`
 }
@@ -74,3 +74,37 @@ func NewExecution(namespace, name string, obj Execution) *Execution {
 	obj.Namespace = namespace
 	return &obj
 }
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PolicyList is a list of Policy resources
+type PolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Policy `json:"items"`
+}
+
+func NewPolicy(namespace, name string, obj Policy) *Policy {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("Policy").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NotificationConfigList is a list of NotificationConfig resources
+type NotificationConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NotificationConfig `json:"items"`
+}
+
+func NewNotificationConfig(namespace, name string, obj NotificationConfig) *NotificationConfig {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("NotificationConfig").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
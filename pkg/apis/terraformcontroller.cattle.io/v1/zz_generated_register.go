@@ -28,9 +28,11 @@ import (
 )
 
 var (
-	ExecutionResourceName = "executions"
-	ModuleResourceName    = "modules"
-	StateResourceName     = "states"
+	ExecutionResourceName          = "executions"
+	ModuleResourceName             = "modules"
+	NotificationConfigResourceName = "notificationconfigs"
+	PolicyResourceName             = "policies"
+	StateResourceName              = "states"
 )
 
 // SchemeGroupVersion is group version used to register these objects
@@ -58,6 +60,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ExecutionList{},
 		&Module{},
 		&ModuleList{},
+		&NotificationConfig{},
+		&NotificationConfigList{},
+		&Policy{},
+		&PolicyList{},
 		&State{},
 		&StateList{},
 	)
@@ -25,11 +25,6 @@ type controllers struct {
 	jobs       batchv1.JobController
 }
 
-const (
-	terraState = "tfstate"
-	terraKey   = "tfstateSecretSuffix"
-)
-
 var controllerCache *controllers
 
 func getControllers(kc, ns string) (*controllers, error) {
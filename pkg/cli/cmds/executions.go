@@ -3,6 +3,7 @@ package cmds
 import (
 	"encoding/base64"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/docker/go-units"
@@ -13,7 +14,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-var simpleRunTableHeaders = []string{"EXECUTION NAME", "STATE NAME", "APPROVAL", "AGE"}
+var simpleRunTableHeaders = []string{"EXECUTION NAME", "STATE NAME", "APPROVAL", "COST", "AGE"}
 
 func ExecutionCommand() cli.Command {
 	return cli.Command{
@@ -265,12 +266,16 @@ func runsListToTableStrings(executions *v1.ExecutionList) [][]string {
 		if approvalStatus, ok := execution.Annotations["approved"]; ok && approvalStatus == "" || approvalStatus == "no" {
 			approved = "False"
 		}
+		if policy := execution.Spec.Approval; policy != nil {
+			approved = approvalCount(execution.Status.Approvals, policy.Approvals)
+		}
 
 		age := units.HumanDuration(time.Now().UTC().Sub(execution.ObjectMeta.CreationTimestamp.Time))
 		values = append(values, []string{
 			execution.Name,
 			execution.Labels["state"],
 			approved,
+			costDelta(execution.Status.Cost),
 			age,
 		})
 	}
@@ -278,6 +283,38 @@ func runsListToTableStrings(executions *v1.ExecutionList) [][]string {
 	return values
 }
 
+// approvalCount formats the users whose latest decision approved the plan out of the required
+// approvals, e.g. 1/2
+func approvalCount(approvals []v1.Approval, required int) string {
+	if required < 1 {
+		required = 1
+	}
+	latest := map[string]string{}
+	for _, approval := range approvals {
+		latest[approval.User] = approval.Decision
+	}
+	count := 0
+	for _, decision := range latest {
+		if decision == "no" {
+			return "False"
+		}
+		count++
+	}
+	return fmt.Sprintf("%d/%d", count, required)
+}
+
+// costDelta formats the monthly cost change of the execution's plan, e.g. +12.50 USD
+func costDelta(estimate *v1.CostEstimate) string {
+	if estimate == nil || estimate.DiffTotalMonthlyCost == "" {
+		return "-"
+	}
+	diff := estimate.DiffTotalMonthlyCost
+	if !strings.HasPrefix(diff, "-") {
+		diff = "+" + diff
+	}
+	return strings.TrimSpace(diff + " " + estimate.Currency)
+}
+
 func saveExecution(kubeConfig, namespace string, execution *v1.Execution) (*v1.Execution, error) {
 	controllers, err := getControllers(kubeConfig, namespace)
 	if err != nil {
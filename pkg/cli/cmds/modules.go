@@ -1,12 +1,16 @@
 package cmds
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
 	"github.com/urfave/cli"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-var simpleModuleTableHeader = []string{"NAME", "GIT REPO"}
+var simpleModuleTableHeader = []string{"NAME", "SOURCE", "PHASE", "LAST CHECK", "ERROR"}
 
 type InvalidArgs struct{}
 
@@ -164,11 +168,47 @@ func moduleListToTableStrings(modules *v1.ModuleList) [][]string {
 	var values [][]string
 
 	for _, module := range modules.Items {
+		lastCheck := ""
+		if !module.Status.LastSuccessfulCheck.IsZero() {
+			lastCheck = module.Status.LastSuccessfulCheck.Format(time.RFC3339)
+		}
 		values = append(values, []string{
 			module.Name,
-			module.Spec.Git.URL,
+			moduleSource(module.Spec),
+			module.Status.Phase,
+			lastCheck,
+			firstLine(moduleError(module)),
 		})
 	}
 
 	return values
 }
+
+func moduleError(module v1.Module) string {
+	if module.Status.LastError == "" && v1.ModuleConditionValid.IsFalse(&module) {
+		return v1.ModuleConditionValid.GetMessage(&module)
+	}
+	return module.Status.LastError
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i > -1 {
+		return s[:i]
+	}
+	return s
+}
+
+func moduleSource(spec v1.ModuleSpec) string {
+	switch {
+	case len(spec.Content) > 0:
+		return "<inline>"
+	case spec.Registry != nil:
+		return fmt.Sprintf("%s/%s/%s", spec.Registry.Namespace, spec.Registry.Name, spec.Registry.Provider)
+	case spec.HTTP != nil:
+		return spec.HTTP.URL
+	case spec.OCI != nil:
+		return spec.OCI.Reference
+	default:
+		return spec.Git.URL
+	}
+}
@@ -1,7 +1,10 @@
 package cmds
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
 	"github.com/rancher/terraform-controller/pkg/terraform/state"
@@ -35,11 +38,11 @@ func StateCommand() cli.Command {
 				Action:    stateShow,
 			},
 			{
-				Name:      "unlock",
-				Usage:     "Clear State File Lock",
-				ArgsUsage: "[STATE]",
+				Name:      "force-unlock",
+				Usage:     "Release a stale state lock through an executor job, defaults to the lock in the state status",
+				ArgsUsage: "[STATE] [LOCK ID]",
 				Action:    stateUnlock,
-				Aliases:   []string{"su"},
+				Aliases:   []string{"unlock", "su"},
 			},
 			{
 				Name:      "create",
@@ -60,6 +63,10 @@ func StateCommand() cli.Command {
 						Name:  "autoconfirm",
 						Usage: "Autoapply TF updates",
 					},
+					cli.StringFlag{
+						Name:  "workspace",
+						Usage: "Terraform workspace to run the module in",
+					},
 					cli.StringSliceFlag{
 						Name:  "secret",
 						Usage: "Name of Kubernetes secret to use during execution (Must be in same namespace and pre-created)",
@@ -83,6 +90,60 @@ func StateCommand() cli.Command {
 				Action:    runState,
 				ArgsUsage: "[STATE NAME]",
 			},
+			{
+				Name:      "replace",
+				Usage:     "Run the state once, replacing the resources at the given addresses.",
+				Action:    replaceState,
+				ArgsUsage: "[STATE NAME] [ADDRESS...]",
+				Flags: []cli.Flag{
+					cli.StringSliceFlag{
+						Name:  "target",
+						Usage: "Limit the run to this resource address, can be repeated",
+					},
+				},
+			},
+			{
+				Name:      "plan",
+				Usage:     "Plan the state for review, without applying or locking it.",
+				Action:    planState,
+				ArgsUsage: "[STATE NAME]",
+				Flags: []cli.Flag{
+					cli.StringSliceFlag{
+						Name:  "target",
+						Usage: "Limit the plan to this resource address, can be repeated",
+					},
+				},
+			},
+			{
+				Name:      "refresh",
+				Usage:     "Update the state to match the infrastructure, without changing resources.",
+				Action:    refreshState,
+				ArgsUsage: "[STATE NAME]",
+			},
+			{
+				Name:      "restore",
+				Usage:     "Restore a snapshot of the state, see the snapshots with 'states show'.",
+				Action:    restoreState,
+				ArgsUsage: "[STATE NAME] [SNAPSHOT]",
+			},
+			{
+				Name:      "import",
+				Usage:     "Import an existing resource into the state.",
+				Action:    importState,
+				ArgsUsage: "[STATE NAME] [ADDRESS] [ID]",
+			},
+			{
+				Name:      "mv",
+				Usage:     "Move a resource to another address in the state.",
+				Action:    moveState,
+				ArgsUsage: "[STATE NAME] [FROM ADDRESS] [TO ADDRESS]",
+			},
+			{
+				Name:      "rm",
+				Usage:     "Remove resources from the state, leaving the infrastructure as is.",
+				Action:    removeState,
+				ArgsUsage: "[STATE NAME] [ADDRESS...]",
+			},
 		},
 	}
 }
@@ -118,7 +179,34 @@ func stateShow(c *cli.Context) error {
 
 	fmt.Printf("State: %s\n", name)
 	fmt.Printf("Auto Confirm: %t\n", state.Spec.AutoConfirm)
-	fmt.Printf("Destroy on Deleted: %t\n\n", state.Spec.AutoConfirm)
+	fmt.Printf("Destroy on Deleted: %t\n", state.Spec.AutoConfirm)
+	workspace := state.Spec.Workspace
+	if workspace == "" {
+		workspace = "default"
+	}
+	fmt.Printf("Workspace: %s\n", workspace)
+	if lock := state.Status.Lock; lock != nil {
+		stale := ""
+		if lock.Stale {
+			stale = ", stale"
+		}
+		fmt.Printf("Lock: %s (%s by %s since %s%s)\n", lock.ID, lock.Operation, lock.Who, lock.Created.Format(time.RFC3339), stale)
+	}
+	fmt.Print("\n")
+
+	if len(state.Status.MissingVariables) > 0 {
+		fmt.Printf("Missing Variables: %s\n", strings.Join(state.Status.MissingVariables, ", "))
+	}
+	if len(state.Status.UnknownVariables) > 0 {
+		fmt.Printf("Unknown Variables: %s\n", strings.Join(state.Status.UnknownVariables, ", "))
+	}
+
+	if len(state.Status.Snapshots) > 0 {
+		fmt.Print("\nSnapshots:\n")
+		for _, snapshot := range state.Status.Snapshots {
+			fmt.Printf("  %s (serial %d, %s, %s)\n", snapshot.Name, snapshot.Serial, snapshot.Store, snapshot.Created.Format(time.RFC3339))
+		}
+	}
 
 	if len(state.Spec.Variables.EnvConfigName) > 0 {
 		fmt.Print("\n")
@@ -160,39 +248,33 @@ func stateShow(c *cli.Context) error {
 func stateUnlock(c *cli.Context) error {
 	kubeConfig := c.GlobalString("kubeconfig")
 	namespace := c.GlobalString("namespace")
-	controllers, err := getControllers(kubeConfig, namespace)
-	if err != nil {
-		return err
-	}
 
 	args := c.Args()
-	if len(args) != 1 {
-		return cli.NewExitError("No state name passed", 1)
+	if len(args) < 1 || len(args) > 2 {
+		return cli.NewExitError("Expects a state name and optionally a lock id", 1)
 	}
-	name := c.Args().First()
+	name := args.First()
 
-	selector := fmt.Sprintf("%s=true,%s=%s", terraState, terraKey, name)
-	secrets, err := controllers.secrets.List(namespace, metav1.ListOptions{
-		LabelSelector: selector,
-	})
-
-	if err != nil {
-		return err
-	}
-
-	for _, secret := range secrets.Items {
-		if secret.Data["lockInfo"] != nil {
-			copyObj := secret.DeepCopy()
-			copyObj.Data["lockInfo"] = nil
-			_, err := controllers.secrets.Update(copyObj)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Unlocking %s", name)
+	lockID := args.Get(1)
+	if lockID == "" {
+		obj, err := getState(namespace, kubeConfig, name)
+		if err != nil {
+			return err
+		}
+		if obj.Status.Lock == nil {
+			return fmt.Errorf("state %s is not locked", name)
+		}
+		if obj.Status.Lock.Job == "" {
+			return fmt.Errorf("lock %s of state %s is held by %s, not by an executor job, pass its id to release it",
+				obj.Status.Lock.ID, name, obj.Status.Lock.Who)
 		}
+		lockID = obj.Status.Lock.ID
 	}
 
-	return nil
+	return setOperation(namespace, kubeConfig, name, v1.Operation{
+		Action: state.ActionForceUnlock,
+		LockID: lockID,
+	})
 }
 
 func createState(c *cli.Context) error {
@@ -212,6 +294,7 @@ func createState(c *cli.Context) error {
 			Image:           c.String("image"),
 			DestroyOnDelete: c.Bool("destroy-on-delete"),
 			AutoConfirm:     c.Bool("autoconfirm"),
+			Workspace:       c.String("workspace"),
 			Variables: v1.Variables{
 				SecretNames:   c.StringSlice("secret"),
 				EnvConfigName: c.StringSlice("configmap"),
@@ -249,6 +332,130 @@ func runState(c *cli.Context) error {
 	return err
 }
 
+func replaceState(c *cli.Context) error {
+	kubeConfig := c.GlobalString("kubeconfig")
+	namespace := c.GlobalString("namespace")
+
+	if len(c.Args()) < 1 {
+		return cli.NewExitError("No state name passed", 1)
+	}
+
+	operation := v1.Operation{
+		Targets: c.StringSlice("target"),
+		Replace: c.Args()[1:],
+	}
+	if len(operation.Targets) == 0 && len(operation.Replace) == 0 {
+		return cli.NewExitError("Expects at least one address to replace or --target.", 1)
+	}
+
+	return setOperation(namespace, kubeConfig, c.Args()[0], operation)
+}
+
+func planState(c *cli.Context) error {
+	kubeConfig := c.GlobalString("kubeconfig")
+	namespace := c.GlobalString("namespace")
+
+	if len(c.Args()) != 1 {
+		return InvalidArgs{}
+	}
+
+	return setOperation(namespace, kubeConfig, c.Args()[0], v1.Operation{
+		Action:  state.ActionPlan,
+		Targets: c.StringSlice("target"),
+	})
+}
+
+func refreshState(c *cli.Context) error {
+	kubeConfig := c.GlobalString("kubeconfig")
+	namespace := c.GlobalString("namespace")
+
+	if len(c.Args()) != 1 {
+		return InvalidArgs{}
+	}
+
+	return setOperation(namespace, kubeConfig, c.Args()[0], v1.Operation{
+		Action: state.ActionRefresh,
+	})
+}
+
+func restoreState(c *cli.Context) error {
+	kubeConfig := c.GlobalString("kubeconfig")
+	namespace := c.GlobalString("namespace")
+
+	if len(c.Args()) != 2 {
+		return cli.NewExitError("Expects a state name and snapshot.", 1)
+	}
+
+	return setOperation(namespace, kubeConfig, c.Args()[0], v1.Operation{
+		Restore: c.Args()[1],
+	})
+}
+
+func importState(c *cli.Context) error {
+	kubeConfig := c.GlobalString("kubeconfig")
+	namespace := c.GlobalString("namespace")
+
+	if len(c.Args()) != 3 {
+		return cli.NewExitError("Expects a state name, address and id.", 1)
+	}
+
+	return setOperation(namespace, kubeConfig, c.Args()[0], v1.Operation{
+		Imports: []v1.ImportOperation{{Address: c.Args()[1], ID: c.Args()[2]}},
+	})
+}
+
+func moveState(c *cli.Context) error {
+	kubeConfig := c.GlobalString("kubeconfig")
+	namespace := c.GlobalString("namespace")
+
+	if len(c.Args()) != 3 {
+		return cli.NewExitError("Expects a state name, from and to address.", 1)
+	}
+
+	return setOperation(namespace, kubeConfig, c.Args()[0], v1.Operation{
+		Moves: []v1.MoveOperation{{From: c.Args()[1], To: c.Args()[2]}},
+	})
+}
+
+func removeState(c *cli.Context) error {
+	kubeConfig := c.GlobalString("kubeconfig")
+	namespace := c.GlobalString("namespace")
+
+	if len(c.Args()) < 2 {
+		return cli.NewExitError("Expects a state name and at least one address.", 1)
+	}
+
+	return setOperation(namespace, kubeConfig, c.Args()[0], v1.Operation{
+		Removes: c.Args()[1:],
+	})
+}
+
+// setOperation requests a one-shot operation through the operation annotation of the state
+func setOperation(namespace, kubeConfig, name string, operation v1.Operation) error {
+	op, err := json.Marshal(operation)
+	if err != nil {
+		return err
+	}
+
+	state, err := getState(namespace, kubeConfig, name)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := state.Annotations[v1.StateOperationAnnotation]; ok {
+		return fmt.Errorf("state %s already has a pending operation", name)
+	}
+
+	copyObj := state.DeepCopy()
+	if copyObj.Annotations == nil {
+		copyObj.Annotations = map[string]string{}
+	}
+	copyObj.Annotations[v1.StateOperationAnnotation] = string(op)
+
+	_, err = saveState(kubeConfig, namespace, copyObj)
+	return err
+}
+
 func deleteState(c *cli.Context) error {
 	kubeConfig := c.GlobalString("kubeconfig")
 	namespace := c.GlobalString("namespace")
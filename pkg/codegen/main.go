@@ -16,6 +16,8 @@ func main() {
 					v1.Module{},
 					v1.State{},
 					v1.Execution{},
+					v1.Policy{},
+					v1.NotificationConfig{},
 				},
 				GenerateTypes: true,
 			},
@@ -0,0 +1,164 @@
+// Package cost estimates the monthly cost change of a terraform plan
+package cost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/pkg/errors"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/executor/terraform"
+)
+
+const (
+	EstimatorInfracost = "infracost"
+	EstimatorPricing   = "pricing"
+
+	// PricingKey is the key of the pricing config map holding the prices
+	PricingKey = "pricing"
+
+	defaultCurrency = "USD"
+)
+
+// Estimator estimates the monthly cost before and after the JSON plan
+type Estimator interface {
+	Estimate(ctx context.Context, plan []byte) (*v1.CostEstimate, error)
+}
+
+// Infracost runs an Infracost compatible CLI as '<command> breakdown --path <plan> --format json'
+type Infracost struct {
+	Command string
+}
+
+// infracostOutput is the part of the Infracost JSON output with the totals, which are
+// decimal strings or null
+type infracostOutput struct {
+	Currency             string  `json:"currency"`
+	PastTotalMonthlyCost *string `json:"pastTotalMonthlyCost"`
+	TotalMonthlyCost     *string `json:"totalMonthlyCost"`
+	DiffTotalMonthlyCost *string `json:"diffTotalMonthlyCost"`
+}
+
+func (i Infracost) Estimate(ctx context.Context, plan []byte) (*v1.CostEstimate, error) {
+	file, err := ioutil.TempFile("", "plan-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(plan); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	command := i.Command
+	if command == "" {
+		command = EstimatorInfracost
+	}
+	out, err := exec.CommandContext(ctx, command, "breakdown", "--path", file.Name(), "--format", "json").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, errors.Wrap(err, string(exitErr.Stderr))
+		}
+		return nil, err
+	}
+
+	output := infracostOutput{}
+	if err := json.Unmarshal(out, &output); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s output", command)
+	}
+	return &v1.CostEstimate{
+		Currency:             output.Currency,
+		PastTotalMonthlyCost: value(output.PastTotalMonthlyCost),
+		TotalMonthlyCost:     value(output.TotalMonthlyCost),
+		DiffTotalMonthlyCost: value(output.DiffTotalMonthlyCost),
+	}, nil
+}
+
+// Pricing prices every managed resource in the plan by its type, resources of types
+// without a price cost nothing
+type Pricing struct {
+	Prices   map[string]float64
+	Currency string
+}
+
+// ParsePricing parses a JSON object of resource types and their monthly cost
+func ParsePricing(data string, currency string) (*Pricing, error) {
+	prices := map[string]float64{}
+	if err := json.Unmarshal([]byte(data), &prices); err != nil {
+		return nil, errors.Wrap(err, "parsing pricing")
+	}
+	return &Pricing{Prices: prices, Currency: currency}, nil
+}
+
+func (p Pricing) Estimate(ctx context.Context, data []byte) (*v1.CostEstimate, error) {
+	plan, err := terraform.ParsePlan(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var past, total float64
+	for _, rc := range plan.ResourceChanges {
+		if rc.Mode != "managed" {
+			continue
+		}
+		price := p.Prices[rc.Type]
+		// no-op and update changes exist before and after, creates only after and deletes
+		// only before, a replace is both
+		if !rc.Change.Creates() || rc.Change.Deletes() {
+			past += price
+		}
+		if !rc.Change.Deletes() || rc.Change.Creates() {
+			total += price
+		}
+	}
+
+	currency := p.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	return &v1.CostEstimate{
+		Currency:             currency,
+		PastTotalMonthlyCost: format(past),
+		TotalMonthlyCost:     format(total),
+		DiffTotalMonthlyCost: format(total - past),
+	}, nil
+}
+
+// ExceedsDelta returns an error describing why the estimate raises the monthly cost by more
+// than max, nil if it doesn't
+func ExceedsDelta(estimate *v1.CostEstimate, max string) error {
+	limit, err := strconv.ParseFloat(max, 64)
+	if err != nil {
+		return fmt.Errorf("invalid maxMonthlyCostDelta %q", max)
+	}
+	if estimate == nil || estimate.DiffTotalMonthlyCost == "" {
+		return errors.New("the monthly cost delta is unknown")
+	}
+	diff, err := strconv.ParseFloat(estimate.DiffTotalMonthlyCost, 64)
+	if err != nil {
+		return fmt.Errorf("invalid monthly cost delta %q", estimate.DiffTotalMonthlyCost)
+	}
+	if diff > limit {
+		return fmt.Errorf("the monthly cost delta %s %s exceeds maxMonthlyCostDelta %s", estimate.DiffTotalMonthlyCost, estimate.Currency, max)
+	}
+	return nil
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func format(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
@@ -0,0 +1,66 @@
+package cost
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const plan = `{
+  "resource_changes": [
+    {"address": "aws_instance.web[0]", "mode": "managed", "type": "aws_instance", "change": {"actions": ["no-op"]}},
+    {"address": "aws_instance.web[1]", "mode": "managed", "type": "aws_instance", "change": {"actions": ["create"]}},
+    {"address": "aws_db_instance.main", "mode": "managed", "type": "aws_db_instance", "change": {"actions": ["delete", "create"]}},
+    {"address": "aws_eip.old", "mode": "managed", "type": "aws_eip", "change": {"actions": ["delete"]}},
+    {"address": "data.aws_ami.ubuntu", "mode": "data", "type": "aws_ami", "change": {"actions": ["read"]}}
+  ]
+}`
+
+func TestPricing(t *testing.T) {
+	pricing, err := ParsePricing(`{"aws_instance": 30.5, "aws_db_instance": 120, "aws_eip": 3.6, "aws_ami": 1000}`, "")
+	require.NoError(t, err)
+
+	estimate, err := pricing.Estimate(context.Background(), []byte(plan))
+	require.NoError(t, err)
+	assert.Equal(t, &v1.CostEstimate{
+		Currency:             "USD",
+		PastTotalMonthlyCost: "154.10",
+		TotalMonthlyCost:     "181.00",
+		DiffTotalMonthlyCost: "26.90",
+	}, estimate)
+}
+
+func TestInfracost(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infracost")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	command := filepath.Join(dir, "infracost")
+	script := `#!/bin/sh
+[ "$1" = breakdown ] && [ "$2" = --path ] && [ -f "$3" ] || exit 1
+echo '{"currency":"EUR","pastTotalMonthlyCost":null,"totalMonthlyCost":"42.5","diffTotalMonthlyCost":"42.5"}'
+`
+	require.NoError(t, ioutil.WriteFile(command, []byte(script), 0755))
+
+	estimate, err := Infracost{Command: command}.Estimate(context.Background(), []byte(plan))
+	require.NoError(t, err)
+	assert.Equal(t, &v1.CostEstimate{
+		Currency:             "EUR",
+		TotalMonthlyCost:     "42.5",
+		DiffTotalMonthlyCost: "42.5",
+	}, estimate)
+}
+
+func TestExceedsDelta(t *testing.T) {
+	estimate := &v1.CostEstimate{Currency: "USD", DiffTotalMonthlyCost: "26.90"}
+	assert.NoError(t, ExceedsDelta(estimate, "50"))
+	assert.EqualError(t, ExceedsDelta(estimate, "10"), "the monthly cost delta 26.90 USD exceeds maxMonthlyCostDelta 10")
+	assert.Error(t, ExceedsDelta(nil, "10"))
+	assert.Error(t, ExceedsDelta(estimate, "ten"))
+}
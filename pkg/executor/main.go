@@ -8,7 +8,7 @@ import (
 	"os"
 
 	"github.com/rancher/terraform-controller/pkg/executor/runner"
-	"github.com/rancher/terraform-controller/pkg/git"
+	"github.com/rancher/terraform-controller/pkg/source"
 	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -26,9 +26,8 @@ func main() {
 	}
 }
 
-func run() error {
+func run() (err error) {
 	var config *rest.Config
-	var err error
 
 	// Useful for running executor locally without having to deploy to k8s
 	if path := os.Getenv("KUBECONFIG"); path != "" {
@@ -55,8 +54,24 @@ func run() error {
 		return err
 	}
 
-	logrus.Info("before clone")
-	err = git.CloneRepo(context.Background(), runner.Execution.Spec.Content.Git.URL, runner.Execution.Spec.Content.Git.Commit, runner.GitAuth)
+	defer func() {
+		if err != nil {
+			if failErr := runner.SetExecutionFailed(err); failErr != nil {
+				logrus.Error(failErr)
+			}
+		}
+	}()
+
+	err = runner.SetupEngine()
+	if err != nil {
+		return err
+	}
+
+	logrus.Info("before fetching module")
+	err = source.Fetch(context.Background(), runner.Execution.Spec.Content, source.Auth{
+		Git: runner.GitAuth,
+		OCI: runner.OCIAuth,
+	}, ".")
 	if err != nil {
 		return err
 	}
@@ -73,11 +88,33 @@ func run() error {
 		return err
 	}
 
+	err = runner.WriteCLIConfig()
+	if err != nil {
+		return err
+	}
+
+	err = runner.RestoreLockFile()
+	if err != nil {
+		return err
+	}
+
 	out, err := runner.TerraformInit()
 	if err != nil {
 		return err
 	}
 
+	err = runner.SaveLockFile()
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(out)
+
+	out, err = runner.SelectWorkspace()
+	if err != nil {
+		return err
+	}
+
 	fmt.Print(out)
 
 	switch runner.Action {
@@ -92,6 +129,8 @@ func run() error {
 			return err
 		}
 
+		snapshot(runner)
+
 		err = runner.SetExecutionRunStatus("applied")
 		if err != nil {
 			return err
@@ -112,14 +151,89 @@ func run() error {
 			return err
 		}
 
+		snapshot(runner)
+
 		err = runner.SetExecutionRunStatus("applied")
 		if err != nil {
 			return err
 		}
 
+	case "refresh":
+		out, err = runner.Refresh()
+		if err != nil {
+			return err
+		}
+
+		err = runner.SetExecutionLogs(out)
+		if err != nil {
+			return err
+		}
+
+		snapshot(runner)
+
+		err = runner.SetExecutionRunStatus("applied")
+		if err != nil {
+			return err
+		}
+	case "plan":
+		out, err = runner.Plan()
+		if err != nil {
+			return err
+		}
+
+		err = runner.SetExecutionLogs(out)
+		if err != nil {
+			return err
+		}
+	case "force-unlock":
+		out, err = runner.ForceUnlock()
+		if err != nil {
+			if logErr := runner.SetExecutionLogs(out + err.Error()); logErr != nil {
+				logrus.Error(logErr)
+			}
+			return err
+		}
+
+		err = runner.SetExecutionLogs(out)
+		if err != nil {
+			return err
+		}
+
+		err = runner.SetExecutionRunStatus("applied")
+		if err != nil {
+			return err
+		}
+	case "state":
+		out, err = runner.ChangeState()
+		if err != nil {
+			if logErr := runner.SetExecutionLogs(out + err.Error()); logErr != nil {
+				logrus.Error(logErr)
+			}
+			return err
+		}
+
+		err = runner.SetExecutionLogs(out)
+		if err != nil {
+			return err
+		}
+
+		snapshot(runner)
+
+		err = runner.SetExecutionRunStatus("applied")
+		if err != nil {
+			return err
+		}
 	default:
-		return errors.New("action is not valid, ony 'create' or 'destroy' allowed")
+		return errors.New("action is not valid, ony 'create', 'destroy', 'refresh', 'plan', 'state' or 'force-unlock' allowed")
 	}
 
 	return runner.DeleteJob()
 }
+
+// snapshot saves a copy of the state after a run changed it, failing to save one doesn't fail
+// the run
+func snapshot(r *runner.Runner) {
+	if err := r.Snapshot(); err != nil {
+		logrus.Errorf("failed to snapshot state: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+// Package policy checks terraform plans against Rego policies with the opa CLI
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	LevelDeny = "deny"
+	LevelWarn = "warn"
+
+	// DefaultPackage is the Rego package evaluated when a policy doesn't set one
+	DefaultPackage = "terraform"
+)
+
+var (
+	// Command is the opa binary of the executor image
+	Command = "opa"
+
+	packagePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+)
+
+// Result holds the messages of the deny and warn rules a plan broke
+type Result struct {
+	Deny []string `json:"deny"`
+	Warn []string `json:"warn"`
+}
+
+// evalOutput is the output of 'opa eval --format json'
+type evalOutput struct {
+	Result []struct {
+		Expressions []struct {
+			Value Result `json:"value"`
+		} `json:"expressions"`
+	} `json:"result"`
+}
+
+// Evaluate evaluates the deny and warn rules of pkg in the Rego modules, keyed by file name,
+// with the JSON plan as input. The rules are sets of messages, as in
+//
+//	deny[msg] { ... msg := "..." }
+func Evaluate(ctx context.Context, pkg string, modules map[string]string, plan []byte) (*Result, error) {
+	if pkg == "" {
+		pkg = DefaultPackage
+	}
+	if !packagePattern.MatchString(pkg) {
+		return nil, fmt.Errorf("invalid package %q", pkg)
+	}
+
+	dir, err := ioutil.TempDir("", "policy")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	moduleDir := filepath.Join(dir, "modules")
+	if err := os.Mkdir(moduleDir, 0700); err != nil {
+		return nil, err
+	}
+	// opa only loads .rego files as modules
+	for name, module := range modules {
+		file := filepath.Base(name)
+		if !strings.HasSuffix(file, ".rego") {
+			file += ".rego"
+		}
+		if err := ioutil.WriteFile(filepath.Join(moduleDir, file), []byte(module), 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	input := filepath.Join(dir, "plan.json")
+	if err := ioutil.WriteFile(input, plan, 0600); err != nil {
+		return nil, err
+	}
+
+	// the comprehensions are empty, rather than undefined, when a rule doesn't exist
+	query := fmt.Sprintf(`{"deny": [m | data.%[1]s.deny[m]], "warn": [m | data.%[1]s.warn[m]]}`, pkg)
+	out, err := exec.CommandContext(ctx, Command, "eval", "--format", "json",
+		"--data", moduleDir, "--input", input, query).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, errors.Wrap(err, strings.TrimSpace(string(exitErr.Stderr)+string(out)))
+		}
+		return nil, err
+	}
+
+	output := evalOutput{}
+	if err := json.Unmarshal(out, &output); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s output", Command)
+	}
+	if len(output.Result) != 1 || len(output.Result[0].Expressions) != 1 {
+		return nil, fmt.Errorf("unexpected %s output: %s", Command, out)
+	}
+
+	result := output.Result[0].Expressions[0].Value
+	sort.Strings(result.Deny)
+	sort.Strings(result.Warn)
+	return &result, nil
+}
@@ -0,0 +1,38 @@
+package policy
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "opa")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the fake opa checks the modules and input were written and answers like opa eval
+	script := `#!/bin/sh
+[ "$1" = eval ] && [ -f "$5/s3.rego" ] && [ -f "$7" ] || exit 1
+case "$8" in
+*data.guardrails.deny*) ;;
+*) exit 1 ;;
+esac
+echo '{"result":[{"expressions":[{"value":{"deny":["public bucket logs","public bucket assets"],"warn":[]}}]}]}'
+`
+	Command = filepath.Join(dir, "opa")
+	require.NoError(t, ioutil.WriteFile(Command, []byte(script), 0755))
+
+	result, err := Evaluate(context.Background(), "guardrails", map[string]string{"s3": "package guardrails"}, []byte(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"public bucket assets", "public bucket logs"}, result.Deny)
+	assert.Empty(t, result.Warn)
+
+	_, err = Evaluate(context.Background(), "guardrails; rm", nil, []byte(`{}`))
+	assert.EqualError(t, err, `invalid package "guardrails; rm"`)
+}
@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/sirupsen/logrus"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// approvalsRecordedEnv is set to true on executor jobs when the controller serves the admission
+// webhook that records approvals
+const approvalsRecordedEnv = "APPROVALS_RECORDED"
+
+// checkApprovalsRecorded returns an error when the execution has an approval policy, but no
+// approvals can be recorded because the admission webhook isn't enabled, so the job fails
+// instead of waiting forever
+func checkApprovalsRecorded(run *v1.Execution) error {
+	if run.Spec.Approval == nil || os.Getenv(approvalsRecordedEnv) == "true" {
+		return nil
+	}
+	return errors.New("the state has an approval policy, but approvals are only recorded by the admission webhook, " +
+		"which the controller doesn't serve: enable the admission webhook or remove spec.approval")
+}
+
+// decision returns the decision on the execution's plan, empty while there is none. Without an
+// approval policy it is the approved annotation. With one it is no once the annotation is no,
+// and yes once enough users approved, as recorded by the admission webhook. The annotation is
+// cleared while approvals are missing, so the next approver can set it again.
+func (r *Runner) decision(run *v1.Execution) string {
+	decision, pending := approvalDecision(run)
+	if pending {
+		if err := r.clearApproval(run.Name); err != nil {
+			logrus.Errorf("failed to clear the approved annotation: %v", err)
+		}
+	}
+	return decision
+}
+
+// approvalDecision returns the decision on the plan, and whether the approved annotation is
+// set while approvals are missing
+func approvalDecision(run *v1.Execution) (string, bool) {
+	annotation := strings.TrimSpace(run.Annotations["approved"])
+	policy := run.Spec.Approval
+	if policy == nil {
+		return annotation, false
+	}
+	if strings.ToLower(annotation) == "no" {
+		return "no", false
+	}
+
+	required := policy.Approvals
+	if required < 1 {
+		required = 1
+	}
+
+	// the latest decision of each user counts
+	latest := map[string]string{}
+	for _, approval := range run.Status.Approvals {
+		latest[approval.User] = approval.Decision
+	}
+	approvals := 0
+	for _, decision := range latest {
+		switch decision {
+		case "no":
+			return "no", false
+		case "yes":
+			approvals++
+		}
+	}
+	if approvals >= required {
+		return "yes", false
+	}
+
+	logrus.Infof("%d of %d approvals recorded", approvals, required)
+	return "", annotation != ""
+}
+
+func (r *Runner) clearApproval(name string) error {
+	return tryUpdate(func() error {
+		exec, err := r.executions.Get(r.Namespace, name, metaV1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if _, ok := exec.Annotations["approved"]; !ok {
+			return nil
+		}
+
+		exec = exec.DeepCopy()
+		delete(exec.Annotations, "approved")
+		_, err = r.executions.Update(exec)
+		return err
+	})
+}
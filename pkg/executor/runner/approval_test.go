@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"os"
+	"testing"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApprovalDecision(t *testing.T) {
+	run := &v1.Execution{
+		ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{"approved": "yes"}},
+	}
+	decision, pending := approvalDecision(run)
+	assert.Equal(t, "yes", decision)
+	assert.False(t, pending)
+
+	run.Spec.Approval = &v1.ApprovalPolicy{Approvals: 2}
+	run.Status.Approvals = []v1.Approval{{User: "bob", Decision: "yes"}, {User: "bob", Decision: "yes"}}
+	decision, pending = approvalDecision(run)
+	assert.Equal(t, "", decision)
+	assert.True(t, pending)
+
+	run.Status.Approvals = append(run.Status.Approvals, v1.Approval{User: "carol", Decision: "yes"})
+	decision, _ = approvalDecision(run)
+	assert.Equal(t, "yes", decision)
+
+	run.Status.Approvals = append(run.Status.Approvals, v1.Approval{User: "bob", Decision: "no"})
+	decision, _ = approvalDecision(run)
+	assert.Equal(t, "no", decision)
+}
+
+func TestCheckApprovalsRecorded(t *testing.T) {
+	defer os.Unsetenv(approvalsRecordedEnv)
+
+	run := &v1.Execution{}
+	assert.NoError(t, checkApprovalsRecorded(run))
+
+	run.Spec.Approval = &v1.ApprovalPolicy{Approvals: 2}
+	assert.Error(t, checkApprovalsRecorded(run))
+
+	os.Setenv(approvalsRecordedEnv, "true")
+	assert.NoError(t, checkApprovalsRecorded(run))
+}
@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/sirupsen/logrus"
+	coreV1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	cliConfigEnv = "TF_CLI_CONFIG_FILE"
+	lockFile     = ".terraform.lock.hcl"
+	lockFileKey  = "lockFile"
+)
+
+// WriteCLIConfig writes a CLI config that caches providers in the executor cache and
+// installs them from the execution's provider mirrors, and points TF_CLI_CONFIG_FILE at it
+func (r *Runner) WriteCLIConfig() error {
+	if os.Getenv(cliConfigEnv) != "" {
+		logrus.Infof("%s is set, not generating a CLI config", cliConfigEnv)
+		return nil
+	}
+
+	pluginDir := ""
+	if cacheDir := os.Getenv("EXECUTOR_CACHE_DIR"); cacheDir != "" {
+		pluginDir = filepath.Join(cacheDir, "plugins")
+		if err := os.MkdirAll(pluginDir, 0755); err != nil {
+			return err
+		}
+	}
+
+	config := cliConfig(pluginDir, r.Execution.Spec.Providers)
+	if config == "" {
+		return nil
+	}
+
+	path := filepath.Join(os.TempDir(), "terraform.rc")
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		return err
+	}
+	return os.Setenv(cliConfigEnv, path)
+}
+
+func cliConfig(pluginDir string, providers *v1.ProviderInstallation) string {
+	var b strings.Builder
+	if pluginDir != "" {
+		fmt.Fprintf(&b, "plugin_cache_dir = %q\n", pluginDir)
+	}
+
+	if providers == nil || (providers.NetworkMirror == "" && providers.FilesystemMirror == "") {
+		return b.String()
+	}
+
+	b.WriteString("\nprovider_installation {\n")
+	if providers.FilesystemMirror != "" {
+		fmt.Fprintf(&b, "  filesystem_mirror {\n    path = %q\n  }\n", providers.FilesystemMirror)
+	}
+	if providers.NetworkMirror != "" {
+		url := providers.NetworkMirror
+		if !strings.HasSuffix(url, "/") {
+			url += "/"
+		}
+		fmt.Fprintf(&b, "  network_mirror {\n    url = %q\n  }\n", url)
+	}
+	if providers.Direct {
+		b.WriteString("  direct {}\n")
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// RestoreLockFile writes the dependency lock file saved by the last run of the state, a
+// lock file committed with the module is used as is
+func (r *Runner) RestoreLockFile() error {
+	if _, err := os.Stat(lockFile); err == nil {
+		logrus.Info("using the dependency lock file of the module")
+		return nil
+	}
+
+	secret, err := r.getSecret(r.lockSecretName())
+	if k8sError.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	data, ok := secret.Data[lockFileKey]
+	if !ok {
+		return nil
+	}
+	return ioutil.WriteFile(lockFile, data, 0644)
+}
+
+// SaveLockFile stores the dependency lock file after init so the next run of the state
+// installs the same provider versions
+func (r *Runner) SaveLockFile() error {
+	data, err := ioutil.ReadFile(lockFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return tryUpdate(func() error {
+		secret, err := r.getSecret(r.lockSecretName())
+		if k8sError.IsNotFound(err) {
+			_, err = r.secrets.Create(&coreV1.Secret{
+				ObjectMeta: metaV1.ObjectMeta{
+					Name:            r.lockSecretName(),
+					Namespace:       r.Execution.Namespace,
+					OwnerReferences: r.Execution.OwnerReferences,
+				},
+				Data: map[string][]byte{
+					lockFileKey: data,
+				},
+			})
+			return err
+		} else if err != nil {
+			return err
+		}
+
+		if string(secret.Data[lockFileKey]) == string(data) {
+			return nil
+		}
+		secret = secret.DeepCopy()
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[lockFileKey] = data
+		_, err = r.secrets.Update(secret)
+		return err
+	})
+}
+
+func (r *Runner) lockSecretName() string {
+	return "lock-" + r.Execution.Spec.ExecutionName
+}
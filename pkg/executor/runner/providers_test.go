@@ -0,0 +1,32 @@
+package runner
+
+import (
+	"testing"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCLIConfig(t *testing.T) {
+	assert.Equal(t, "", cliConfig("", nil))
+
+	assert.Equal(t, `plugin_cache_dir = "/var/cache/terraform-controller/plugins"
+`, cliConfig("/var/cache/terraform-controller/plugins", &v1.ProviderInstallation{LockFileReadonly: true}))
+
+	assert.Equal(t, `plugin_cache_dir = "/cache/plugins"
+
+provider_installation {
+  filesystem_mirror {
+    path = "/usr/share/terraform/providers"
+  }
+  network_mirror {
+    url = "https://mirror.example.com/providers/"
+  }
+  direct {}
+}
+`, cliConfig("/cache/plugins", &v1.ProviderInstallation{
+		NetworkMirror:    "https://mirror.example.com/providers",
+		FilesystemMirror: "/usr/share/terraform/providers",
+		Direct:           true,
+	}))
+}
@@ -0,0 +1,274 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/pkg/errors"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/executor/cost"
+	"github.com/rancher/terraform-controller/pkg/executor/policy"
+	"github.com/rancher/terraform-controller/pkg/executor/terraform"
+	"github.com/sirupsen/logrus"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// planReview is what reviewPlan found in the plan
+type planReview struct {
+	// approvals are the reasons the plan has to be approved even when AutoConfirm is set
+	approvals []string
+	// denials are the reasons the plan must not be applied
+	denials []string
+}
+
+// reviewPlan inspects the saved plan, recording what it finds on the execution
+func (r *Runner) reviewPlan() (*planReview, error) {
+	spec := r.Execution.Spec
+	review := &planReview{}
+	if spec.CostEstimation == nil && spec.MaxMonthlyCostDelta == "" && spec.PolicySelector == nil &&
+		!spec.RequireApprovalOnDestroy && len(spec.ProtectedResourceTypes) == 0 {
+		return review, nil
+	}
+
+	plan, err := terraform.ShowPlan()
+	if err != nil {
+		return nil, err
+	}
+
+	reasons, err := r.protectChanges(plan)
+	if err != nil {
+		return nil, err
+	}
+	review.approvals = append(review.approvals, reasons...)
+
+	if spec.CostEstimation != nil || spec.MaxMonthlyCostDelta != "" {
+		estimate, err := r.estimateCost(plan)
+		if err != nil {
+			logrus.Errorf("failed to estimate the cost of the plan: %v", err)
+		} else if estimate != nil {
+			logrus.Infof("monthly cost %s %s, a change of %s", estimate.TotalMonthlyCost, estimate.Currency, estimate.DiffTotalMonthlyCost)
+			if err := r.SetExecutionCost(estimate); err != nil {
+				return nil, err
+			}
+		}
+		if spec.MaxMonthlyCostDelta != "" {
+			// a plan of unknown cost is never applied without approval
+			if err := cost.ExceedsDelta(estimate, spec.MaxMonthlyCostDelta); err != nil {
+				review.approvals = append(review.approvals, err.Error())
+			}
+		}
+	}
+
+	if spec.PolicySelector != nil {
+		violations, err := r.checkPolicies(plan)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.SetExecutionPolicyViolations(violations); err != nil {
+			return nil, err
+		}
+		for _, v := range violations {
+			if v.Level == policy.LevelDeny {
+				review.denials = append(review.denials, fmt.Sprintf("policy %s: %s", v.Policy, v.Message))
+			} else {
+				logrus.Warnf("policy %s: %s", v.Policy, v.Message)
+			}
+		}
+	}
+
+	return review, nil
+}
+
+// protectChanges returns the reasons the deletes and replaces in the plan need approval
+func (r *Runner) protectChanges(plan []byte) ([]string, error) {
+	spec := r.Execution.Spec
+	if !spec.RequireApprovalOnDestroy && len(spec.ProtectedResourceTypes) == 0 {
+		return nil, nil
+	}
+
+	parsed, err := terraform.ParsePlan(plan)
+	if err != nil {
+		return nil, err
+	}
+	return destructiveChanges(parsed, spec.RequireApprovalOnDestroy, spec.ProtectedResourceTypes)
+}
+
+// destructiveChanges returns the deletes and replaces in the plan that need approval, all of
+// them when all is set, otherwise those of resource types matching a protected pattern
+func destructiveChanges(plan *terraform.JSONPlan, all bool, protected []string) ([]string, error) {
+	var reasons []string
+	for _, rc := range plan.ResourceChanges {
+		if rc.Mode != "managed" || !rc.Change.Deletes() {
+			continue
+		}
+
+		verb := "deletes"
+		if rc.Change.Creates() {
+			verb = "replaces"
+		}
+
+		if all {
+			reasons = append(reasons, fmt.Sprintf("the plan %s %s", verb, rc.Address))
+			continue
+		}
+		for _, pattern := range protected {
+			ok, err := path.Match(pattern, rc.Type)
+			if err != nil {
+				return nil, fmt.Errorf("invalid protectedResourceTypes pattern %q", pattern)
+			}
+			if ok {
+				reasons = append(reasons, fmt.Sprintf("the plan %s %s of protected type %s", verb, rc.Address, rc.Type))
+				break
+			}
+		}
+	}
+	return reasons, nil
+}
+
+// checkPolicies evaluates the Policies selected by the execution against the plan
+func (r *Runner) checkPolicies(plan []byte) ([]v1.PolicyViolation, error) {
+	selector, err := metaV1.LabelSelectorAsSelector(r.Execution.Spec.PolicySelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid policySelector")
+	}
+	policies, err := r.policies.List(r.Namespace, metaV1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	if len(policies.Items) == 0 {
+		logrus.Warnf("policySelector %s matches no policies", selector)
+	}
+
+	var violations []v1.PolicyViolation
+	for _, p := range policies.Items {
+		modules, err := r.policyModules(&p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "policy %s", p.Name)
+		}
+		result, err := policy.Evaluate(context.Background(), p.Spec.Package, modules, plan)
+		if err != nil {
+			return nil, errors.Wrapf(err, "evaluating policy %s", p.Name)
+		}
+		logrus.Infof("checked the plan against policy %s: %d denied, %d warnings", p.Name, len(result.Deny), len(result.Warn))
+		for _, msg := range result.Deny {
+			violations = append(violations, v1.PolicyViolation{Policy: p.Name, Level: policy.LevelDeny, Message: msg})
+		}
+		for _, msg := range result.Warn {
+			violations = append(violations, v1.PolicyViolation{Policy: p.Name, Level: policy.LevelWarn, Message: msg})
+		}
+	}
+	return violations, nil
+}
+
+// policyModules returns the Rego modules of the policy and of the config maps it references
+func (r *Runner) policyModules(p *v1.Policy) (map[string]string, error) {
+	modules := map[string]string{}
+	for name, module := range p.Spec.Modules {
+		modules[name] = module
+	}
+	for _, name := range p.Spec.ConfigMapNames {
+		cm, err := r.configMaps.Get(p.Namespace, name, metaV1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for key, module := range cm.Data {
+			modules[name+"-"+key] = module
+		}
+	}
+	if len(modules) == 0 {
+		return nil, errors.New("no Rego modules")
+	}
+	return modules, nil
+}
+
+// estimateCost returns the cost estimate of the plan, nil if cost estimation isn't set
+func (r *Runner) estimateCost(plan []byte) (*v1.CostEstimate, error) {
+	estimator, err := r.costEstimator()
+	if err != nil || estimator == nil {
+		return nil, err
+	}
+	return estimator.Estimate(context.Background(), plan)
+}
+
+func (r *Runner) costEstimator() (cost.Estimator, error) {
+	config := r.Execution.Spec.CostEstimation
+	if config == nil {
+		return nil, nil
+	}
+
+	switch config.Estimator {
+	case cost.EstimatorInfracost:
+		return cost.Infracost{Command: config.Command}, nil
+	case cost.EstimatorPricing:
+		if config.PricingConfigName == "" {
+			return nil, fmt.Errorf("the %s estimator needs pricingConfigName", cost.EstimatorPricing)
+		}
+		cm, err := r.configMaps.Get(r.Namespace, config.PricingConfigName, metaV1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		pricing, ok := cm.Data[cost.PricingKey]
+		if !ok {
+			return nil, fmt.Errorf("no %s data found in config map %v", cost.PricingKey, cm.Name)
+		}
+		return cost.ParsePricing(pricing, config.Currency)
+	default:
+		return nil, fmt.Errorf("unknown cost estimator %q", config.Estimator)
+	}
+}
+
+// SetExecutionCost records the cost estimate of the plan on the execution
+func (r *Runner) SetExecutionCost(estimate *v1.CostEstimate) error {
+	return tryUpdate(func() error {
+		exec, err := r.getExecution(r.Namespace, r.Execution.Name)
+		if err != nil {
+			return err
+		}
+
+		copy := exec.DeepCopy()
+		copy.Status.Cost = estimate
+
+		exec, err = r.executions.Update(copy)
+		if err != nil {
+			return err
+		}
+		r.Execution = exec
+		return nil
+	})
+}
+
+// SetExecutionPolicyViolations records the policy rules the plan broke on the execution
+func (r *Runner) SetExecutionPolicyViolations(violations []v1.PolicyViolation) error {
+	return tryUpdate(func() error {
+		exec, err := r.getExecution(r.Namespace, r.Execution.Name)
+		if err != nil {
+			return err
+		}
+
+		copy := exec.DeepCopy()
+		copy.Status.PolicyViolations = violations
+
+		exec, err = r.executions.Update(copy)
+		if err != nil {
+			return err
+		}
+		r.Execution = exec
+		return nil
+	})
+}
+
+// reviewDestroy returns the reasons the saved destroy plan has to be approved even when
+// AutoConfirm is set
+func (r *Runner) reviewDestroy() ([]string, error) {
+	spec := r.Execution.Spec
+	if !spec.RequireApprovalOnDestroy && len(spec.ProtectedResourceTypes) == 0 {
+		return nil, nil
+	}
+
+	plan, err := terraform.ShowPlan()
+	if err != nil {
+		return nil, err
+	}
+	return r.protectChanges(plan)
+}
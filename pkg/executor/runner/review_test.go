@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/rancher/terraform-controller/pkg/executor/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDestructiveChanges(t *testing.T) {
+	plan, err := terraform.ParsePlan([]byte(`{
+  "resource_changes": [
+    {"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "change": {"actions": ["update"]}},
+    {"address": "aws_db_instance.main", "mode": "managed", "type": "aws_db_instance", "change": {"actions": ["create", "delete"]}},
+    {"address": "aws_eip.old", "mode": "managed", "type": "aws_eip", "change": {"actions": ["delete"]}},
+    {"address": "data.aws_db_instance.replica", "mode": "data", "type": "aws_db_instance", "change": {"actions": ["delete"]}}
+  ]
+}`))
+	require.NoError(t, err)
+
+	reasons, err := destructiveChanges(plan, false, nil)
+	require.NoError(t, err)
+	assert.Empty(t, reasons)
+
+	reasons, err = destructiveChanges(plan, true, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"the plan replaces aws_db_instance.main",
+		"the plan deletes aws_eip.old",
+	}, reasons)
+
+	reasons, err = destructiveChanges(plan, false, []string{"aws_instance", "aws_db_*"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"the plan replaces aws_db_instance.main of protected type aws_db_instance"}, reasons)
+
+	_, err = destructiveChanges(plan, false, []string{"aws_[db"})
+	assert.Error(t, err)
+}
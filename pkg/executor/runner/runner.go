@@ -1,11 +1,13 @@
 package runner
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	tfv1 "github.com/rancher/terraform-controller/pkg/generated/controllers/terraformcontroller.cattle.io/v1"
 	"github.com/rancher/terraform-controller/pkg/git"
 	"github.com/rancher/terraform-controller/pkg/gz"
+	"github.com/rancher/terraform-controller/pkg/oci"
 	batchcontroller "github.com/rancher/wrangler/pkg/generated/controllers/batch"
 	batchv1 "github.com/rancher/wrangler/pkg/generated/controllers/batch/v1"
 	corecontroller "github.com/rancher/wrangler/pkg/generated/controllers/core"
@@ -28,8 +31,10 @@ import (
 	"k8s.io/client-go/rest"
 )
 
+var workspacePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
 const (
-	approvalMessage = `autoConfirm is not set on the executionRun, and the annotation 'approved' is empty.
+	approvalMessage = `autoConfirm is not set on the executionRun, or the plan needs approval, and the annotation 'approved' is empty.
 Please review the plan and set the annotation 'approved' to 'yes' if approved
 or 'no' if not approved. If set to 'no' the job will exit without making any changes.
 `
@@ -40,9 +45,13 @@ type Runner struct {
 	Namespace  string
 	Execution  *v1.Execution
 	GitAuth    *git.Auth
+	OCIAuth    oci.Auth
 	K8sClient  *kubernetes.Clientset
 	executions tfv1.ExecutionController
+	states     tfv1.StateController
+	policies   tfv1.PolicyController
 	secrets    corev1.SecretController
+	configMaps corev1.ConfigMapController
 	jobs       batchv1.JobController
 	VarSecret  *coreV1.Secret
 }
@@ -67,14 +76,55 @@ func NewRunner(config *rest.Config) (*Runner, error) {
 	}
 
 	r.executions = tfFactory.Terraformcontroller().V1().Execution()
+	r.states = tfFactory.Terraformcontroller().V1().State()
+	r.policies = tfFactory.Terraformcontroller().V1().Policy()
 	r.secrets = coreFactory.Core().V1().Secret()
+	r.configMaps = coreFactory.Core().V1().ConfigMap()
 	r.jobs = batchFactory.Batch().V1().Job()
 
 	return &r, nil
 }
 
+// SetupEngine selects the engine of the execution and downloads the release set as its
+// terraformVersion, the binary of the image is used when no version is set
+func (r *Runner) SetupEngine() error {
+	engine, err := terraform.GetEngine(r.Execution.Spec.Engine)
+	if err != nil {
+		return err
+	}
+	terraform.SetBinary(engine.Name)
+
+	version := r.Execution.Spec.TerraformVersion
+	if version == "" {
+		return nil
+	}
+
+	cacheDir := os.Getenv("EXECUTOR_CACHE_DIR")
+	if cacheDir == "" {
+		cacheDir = os.TempDir()
+	}
+
+	path, err := engine.Install(context.Background(), os.Getenv(engine.MirrorEnv), version, cacheDir)
+	if err != nil {
+		return err
+	}
+	terraform.SetBinary(path)
+	return nil
+}
+
+// SelectWorkspace switches to the workspace of the execution, creating it if needed
+func (r *Runner) SelectWorkspace() (string, error) {
+	if r.Execution.Spec.Workspace == "" {
+		return "", nil
+	}
+	return terraform.SelectWorkspace(r.Execution.Spec.Workspace)
+}
+
 // TerraformInit runs the terraform init command
 func (r *Runner) TerraformInit() (string, error) {
+	if providers := r.Execution.Spec.Providers; providers != nil && providers.LockFileReadonly {
+		return terraform.Init("-lockfile=readonly")
+	}
 	return terraform.Init()
 }
 
@@ -82,28 +132,90 @@ func (r *Runner) TerraformInit() (string, error) {
 // set it will run 'plan' then 'apply', if the flag is not set 'plan' will run then
 // the job will wait for the approved annotation to be set then the job will run 'apply' or exit.
 func (r *Runner) Create() (string, error) {
-	out, err := terraform.Plan(false)
+	return r.planAndApply(planArgs(r.Execution.Spec.Operation)...)
+}
+
+// Refresh updates the state to match the infrastructure with a refresh-only plan, which is
+// approved and applied like the plan of Create
+func (r *Runner) Refresh() (string, error) {
+	return r.planAndApply(append([]string{"-refresh-only"}, planArgs(r.Execution.Spec.Operation)...)...)
+}
+
+// Plan only runs 'terraform plan'. It doesn't take the state lock, so a plan for review never
+// blocks, or is blocked by, an apply.
+func (r *Runner) Plan() (string, error) {
+	out, err := terraform.Plan(false, append([]string{"-lock=false"}, planArgs(r.Execution.Spec.Operation)...)...)
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.SetExecutionPlanSummary(out); err != nil {
+		return out, err
+	}
+	if err := r.SetExecutionRunStatus("planned"); err != nil {
+		return out, err
+	}
+
+	review, err := r.reviewPlan()
+	if err != nil {
+		return out, err
+	}
+	for _, reason := range review.denials {
+		out += fmt.Sprintf("\nThis plan can't be applied: %s", reason)
+	}
+	for _, reason := range review.approvals {
+		out += fmt.Sprintf("\nApplying this plan needs approval: %s", reason)
+	}
+	return out, nil
+}
+
+func (r *Runner) planAndApply(args ...string) (string, error) {
+	out, err := terraform.Plan(false, args...)
 	if err != nil {
 		return "", err
 	}
 
 	fmt.Println(out)
 
+	err = r.SetExecutionPlanSummary(out)
+	if err != nil {
+		return "", err
+	}
+
 	err = r.SetExecutionRunStatus("planned")
 	if err != nil {
 		return "", err
 	}
 
+	review, err := r.reviewPlan()
+	if err != nil {
+		return "", err
+	}
+	if len(review.denials) > 0 {
+		return "", fmt.Errorf("plan denied, no changes applied: %s", strings.Join(review.denials, "; "))
+	}
+
 	// We have autoConfirm, run apply
 	if r.Execution.Spec.AutoConfirm {
-		logrus.Info("We have autoConfirm, running apply")
-		return terraform.Apply()
+		if len(review.approvals) == 0 {
+			logrus.Info("We have autoConfirm, running apply")
+			return terraform.Apply()
+		}
+		for _, reason := range review.approvals {
+			fmt.Printf("autoConfirm is set, but the plan needs approval: %s\n", reason)
+		}
 	}
 
 	// Need to wait for approval before running apply
-	approval, ok := r.Execution.Annotations["approved"]
-	if !ok || approval == "" {
+	approval := r.decision(r.Execution)
+	if approval == "" {
+		if err := checkApprovalsRecorded(r.Execution); err != nil {
+			return "", err
+		}
 		fmt.Print(approvalMessage)
+		if err := r.SetExecutionRunStatus("awaitingApproval"); err != nil {
+			return "", err
+		}
 		approval, err = r.waitForApproval()
 		if err != nil {
 			return "", err
@@ -121,6 +233,75 @@ func (r *Runner) Create() (string, error) {
 	}
 }
 
+// ChangeState restores the snapshot of the execution's operation, or runs its imports, moves
+// and removes in that order, returning the output of every command run
+func (r *Runner) ChangeState() (string, error) {
+	op := r.Execution.Spec.Operation
+	if op == nil {
+		return "", errors.New("state action needs an execution with an operation")
+	}
+
+	if op.Restore != "" {
+		return r.Restore(op.Restore)
+	}
+
+	var b strings.Builder
+	for _, i := range op.Imports {
+		out, err := terraform.Import(i.Address, i.ID)
+		b.WriteString(out)
+		if err != nil {
+			return b.String(), err
+		}
+	}
+	for _, m := range op.Moves {
+		out, err := terraform.StateMove(m.From, m.To)
+		b.WriteString(out)
+		if err != nil {
+			return b.String(), err
+		}
+	}
+	if len(op.Removes) > 0 {
+		out, err := terraform.StateRemove(op.Removes...)
+		b.WriteString(out)
+		if err != nil {
+			return b.String(), err
+		}
+	}
+	return b.String(), nil
+}
+
+// ForceUnlock releases the backend lock named by the execution's operation, the controller
+// has already checked the job holding it is gone
+func (r *Runner) ForceUnlock() (string, error) {
+	op := r.Execution.Spec.Operation
+	if op == nil || op.LockID == "" {
+		return "", errors.New("force-unlock action needs an execution with a lock id")
+	}
+
+	audit := fmt.Sprintf("Force unlocking lock %s of state %s/%s for execution %s\n",
+		op.LockID, r.Namespace, r.Execution.Spec.ExecutionName, r.Execution.Name)
+	logrus.Info(strings.TrimSpace(audit))
+
+	out, err := terraform.ForceUnlock(op.LockID)
+	return audit + out, err
+}
+
+// planArgs returns the -target and -replace args of a one-shot operation
+func planArgs(op *v1.Operation) []string {
+	if op == nil {
+		return nil
+	}
+
+	var args []string
+	for _, address := range op.Targets {
+		args = append(args, "-target="+address)
+	}
+	for _, address := range op.Replace {
+		args = append(args, "-replace="+address)
+	}
+	return args
+}
+
 // Destroy will destroy resources through terraform. If the execution AutoConfirm flag is
 // set it will run 'destroy', if the flag is not set 'destroy' will run then
 // the job will wait for the approved approved to be set then the job will run 'destroy'
@@ -131,18 +312,37 @@ func (r *Runner) Destroy() (string, error) {
 		return "", err
 	}
 
+	if err := r.SetExecutionPlanSummary(out); err != nil {
+		return "", err
+	}
+
 	fmt.Println(out)
 
 	// We have autoConfirm, run destroy
 	if r.Execution.Spec.AutoConfirm {
-		logrus.Info("We have autoConfirm, running destroy")
-		return terraform.Destroy()
+		reasons, err := r.reviewDestroy()
+		if err != nil {
+			return "", err
+		}
+		if len(reasons) == 0 {
+			logrus.Info("We have autoConfirm, running destroy")
+			return terraform.Destroy()
+		}
+		for _, reason := range reasons {
+			fmt.Printf("autoConfirm is set, but the plan needs approval: %s\n", reason)
+		}
 	}
 
 	// Need to wait for approval before running apply
-	approval, ok := r.Execution.Annotations["approved"]
-	if !ok || approval == "" {
+	approval := r.decision(r.Execution)
+	if approval == "" {
+		if err := checkApprovalsRecorded(r.Execution); err != nil {
+			return "", err
+		}
 		fmt.Print(approvalMessage)
+		if err := r.SetExecutionRunStatus("awaitingApproval"); err != nil {
+			return "", err
+		}
 		approval, err = r.waitForApproval()
 		if err != nil {
 			return "", err
@@ -223,6 +423,21 @@ func (r *Runner) Populate() error {
 		r.GitAuth = &git.Auth{}
 	}
 
+	if location := r.Execution.Spec.Content.OCI; location != nil && location.SecretName != "" {
+		pullSecret, err := r.getSecret(location.SecretName)
+		if err != nil {
+			return err
+		}
+		ref, err := oci.ParseReference(location.Reference)
+		if err != nil {
+			return err
+		}
+		r.OCIAuth, err = oci.FromSecret(pullSecret.Data, ref.Host)
+		if err != nil {
+			return err
+		}
+	}
+
 	vSecret, err := r.getSecret(r.Execution.Spec.SecretName)
 
 	if err != nil {
@@ -243,6 +458,8 @@ func (r *Runner) SetExecutionRunStatus(s string) error {
 		switch s {
 		case "planned":
 			v1.ExecutionRunConditionPlanned.True(run)
+		case "awaitingApproval":
+			v1.ExecutionRunConditionAwaitingApproval.True(run)
 		case "applied":
 			v1.ExecutionRunConditionApplied.True(run)
 		default:
@@ -258,6 +475,48 @@ func (r *Runner) SetExecutionRunStatus(s string) error {
 	})
 }
 
+// SetExecutionPlanSummary records the summary line of the plan output on the execution
+func (r *Runner) SetExecutionPlanSummary(out string) error {
+	return tryUpdate(func() error {
+		exec, err := r.getExecution(r.Namespace, r.Execution.Name)
+		if err != nil {
+			return err
+		}
+
+		copy := exec.DeepCopy()
+		copy.Status.PlanSummary = terraform.PlanSummary(out)
+
+		exec, err = r.executions.Update(copy)
+		if err != nil {
+			return err
+		}
+		r.Execution = exec
+		return nil
+	})
+}
+
+// SetExecutionFailed sets the Failed condition of the execution with the error the run
+// failed with
+func (r *Runner) SetExecutionFailed(runErr error) error {
+	return tryUpdate(func() error {
+		exec, err := r.getExecution(r.Namespace, r.Execution.Name)
+		if err != nil {
+			return err
+		}
+
+		copy := exec.DeepCopy()
+		v1.ExecutionRunConditionFailed.True(copy)
+		v1.ExecutionRunConditionFailed.Message(copy, runErr.Error())
+
+		exec, err = r.executions.Update(copy)
+		if err != nil {
+			return err
+		}
+		r.Execution = exec
+		return nil
+	})
+}
+
 func (r *Runner) SetExecutionLogs(s string) error {
 	return tryUpdate(func() error {
 		exec, err := r.getExecution(r.Namespace, r.Execution.Name)
@@ -282,7 +541,14 @@ func (r *Runner) SetExecutionLogs(s string) error {
 	})
 }
 
+// WriteConfigFile configures the kubernetes backend. Its state secrets are named
+// tfstate-<workspace>-<suffix>, so the states of each workspace are kept apart while the
+// suffix stays the state name.
 func (r *Runner) WriteConfigFile() error {
+	if ws := r.Execution.Spec.Workspace; ws != "" && !workspacePattern.MatchString(ws) {
+		return fmt.Errorf("invalid workspace %q, it is used in secret names and must be a lowercase RFC 1123 label", ws)
+	}
+
 	config := Config{
 		Terraform: Terraform{
 			Backend: map[string]*Backend{
@@ -363,9 +629,9 @@ func (r *Runner) waitForApproval() (string, error) {
 			continue //wait longer
 		}
 
-		approval, ok := run.Annotations["approved"]
-		logrus.Debugf("approval: %v, ok: %v, len: %v\n", approval, ok, len(approval))
-		if !ok || strings.Trim(approval, " ") == "" {
+		approval := r.decision(run)
+		logrus.Debugf("approval: %v\n", approval)
+		if approval == "" {
 			continue //wait longer
 		}
 
@@ -0,0 +1,16 @@
+package runner
+
+import (
+	"testing"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanArgs(t *testing.T) {
+	assert.Nil(t, planArgs(nil))
+	assert.Equal(t, []string{"-target=module.db", "-replace=aws_instance.web[0]"}, planArgs(&v1.Operation{
+		Targets: []string{"module.db"},
+		Replace: []string{"aws_instance.web[0]"},
+	}))
+}
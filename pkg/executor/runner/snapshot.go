@@ -0,0 +1,318 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/executor/terraform"
+	"github.com/rancher/terraform-controller/pkg/s3"
+	"github.com/sirupsen/logrus"
+	coreV1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	SnapshotStoreSecret = "secret"
+	SnapshotStoreS3     = "s3"
+	SnapshotStoreNone   = "none"
+
+	defaultSnapshotRetention = 10
+	snapshotKey              = "tfstate"
+)
+
+// snapshotStore saves copies of the terraform state
+type snapshotStore interface {
+	// name returns the name the snapshot taken by execution is stored as
+	name(execution string) string
+	save(ctx context.Context, name string, data []byte) error
+	load(ctx context.Context, name string) ([]byte, error)
+	delete(ctx context.Context, name string) error
+}
+
+// stateFile is the part of the terraform state snapshots are checked with
+type stateFile struct {
+	Serial  int64  `json:"serial"`
+	Lineage string `json:"lineage"`
+}
+
+// Snapshot saves a copy of the state to the snapshot store and records it in the state's
+// status, removing the oldest snapshots beyond the retention
+func (r *Runner) Snapshot() error {
+	kind := os.Getenv("SNAPSHOT_STORE")
+	if kind == "" {
+		kind = SnapshotStoreSecret
+	}
+	if kind == SnapshotStoreNone {
+		return nil
+	}
+
+	store, err := r.snapshotStore(kind)
+	if err != nil {
+		return err
+	}
+
+	data, err := terraform.StatePull()
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		logrus.Info("state is empty, not taking a snapshot")
+		return nil
+	}
+
+	file := stateFile{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return errors.Wrap(err, "parsing state")
+	}
+
+	ctx := context.Background()
+	snapshot := v1.StateSnapshot{
+		Name:      store.name(r.Execution.Name),
+		Store:     kind,
+		Serial:    file.Serial,
+		Lineage:   file.Lineage,
+		Execution: r.Execution.Name,
+		Workspace: r.Execution.Spec.Workspace,
+		Created:   metaV1.Now(),
+	}
+	if err := store.save(ctx, snapshot.Name, data); err != nil {
+		return errors.Wrapf(err, "saving snapshot %s", snapshot.Name)
+	}
+	logrus.Infof("saved snapshot %s of state serial %d", snapshot.Name, snapshot.Serial)
+
+	retention := defaultSnapshotRetention
+	if value := os.Getenv("SNAPSHOT_RETENTION"); value != "" {
+		if retention, err = strconv.Atoi(value); err != nil || retention < 1 {
+			return fmt.Errorf("invalid SNAPSHOT_RETENTION %q", value)
+		}
+	}
+
+	var expired []v1.StateSnapshot
+	err = tryUpdate(func() error {
+		state, err := r.states.Get(r.Namespace, r.Execution.Spec.ExecutionName, metaV1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		// tryUpdate may call this again after the update succeeded
+		for _, s := range state.Status.Snapshots {
+			if s.Name == snapshot.Name {
+				return nil
+			}
+		}
+
+		state = state.DeepCopy()
+		expired = nil
+		snapshots := append(state.Status.Snapshots, snapshot)
+		if len(snapshots) > retention {
+			expired = snapshots[:len(snapshots)-retention]
+			snapshots = snapshots[len(snapshots)-retention:]
+		}
+		state.Status.Snapshots = snapshots
+		_, err = r.states.Update(state)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, old := range expired {
+		oldStore, err := r.snapshotStore(old.Store)
+		if err == nil {
+			err = oldStore.delete(ctx, old.Name)
+		}
+		if err != nil {
+			logrus.Errorf("failed to delete expired snapshot %s: %v", old.Name, err)
+		}
+	}
+	return nil
+}
+
+// Restore pushes the snapshot back as the state. It only restores snapshots of the same
+// lineage, and gives the snapshot the serial after the current one so that terraform accepts
+// it without forcing the push.
+func (r *Runner) Restore(name string) (string, error) {
+	state, err := r.states.Get(r.Namespace, r.Execution.Spec.ExecutionName, metaV1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	var snapshot *v1.StateSnapshot
+	for i := range state.Status.Snapshots {
+		if state.Status.Snapshots[i].Name == name {
+			snapshot = &state.Status.Snapshots[i]
+		}
+	}
+	if snapshot == nil {
+		return "", fmt.Errorf("state %s has no snapshot %s", state.Name, name)
+	}
+
+	store, err := r.snapshotStore(snapshot.Store)
+	if err != nil {
+		return "", err
+	}
+	data, err := store.load(context.Background(), snapshot.Name)
+	if err != nil {
+		return "", errors.Wrapf(err, "loading snapshot %s", snapshot.Name)
+	}
+
+	current := stateFile{}
+	currentData, err := terraform.StatePull()
+	if err != nil {
+		return "", err
+	}
+	if len(bytes.TrimSpace(currentData)) > 0 {
+		if err := json.Unmarshal(currentData, &current); err != nil {
+			return "", errors.Wrap(err, "parsing current state")
+		}
+	}
+
+	restored, err := nextSerial(data, current)
+	if err != nil {
+		return "", errors.Wrapf(err, "snapshot %s", snapshot.Name)
+	}
+
+	file, err := ioutil.TempFile("", "restore-*.tfstate")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(restored); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	audit := fmt.Sprintf("Restoring snapshot %s of serial %d over serial %d of state %s/%s\n",
+		snapshot.Name, snapshot.Serial, current.Serial, r.Namespace, state.Name)
+	logrus.Info(strings.TrimSpace(audit))
+	out, err := terraform.StatePush(file.Name())
+	return audit + out, err
+}
+
+// nextSerial returns the snapshot data with the serial following the current state's, after
+// checking the snapshot belongs to the same state
+func nextSerial(data []byte, current stateFile) ([]byte, error) {
+	snapshot := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&snapshot); err != nil {
+		return nil, errors.Wrap(err, "parsing state")
+	}
+
+	lineage, _ := snapshot["lineage"].(string)
+	if current.Lineage != "" && lineage != current.Lineage {
+		return nil, fmt.Errorf("lineage %s does not match the current state's lineage %s", lineage, current.Lineage)
+	}
+
+	snapshot["serial"] = current.Serial + 1
+	return json.MarshalIndent(snapshot, "", "  ")
+}
+
+func (r *Runner) snapshotStore(kind string) (snapshotStore, error) {
+	switch kind {
+	case SnapshotStoreSecret:
+		return &secretStore{runner: r}, nil
+	case SnapshotStoreS3:
+		secretName := os.Getenv("SNAPSHOT_S3_SECRET")
+		if secretName == "" {
+			return nil, errors.New("SNAPSHOT_S3_SECRET is not set")
+		}
+		secret, err := r.getSecret(secretName)
+		if err != nil {
+			return nil, err
+		}
+		return &s3Store{
+			client: s3.NewClient(os.Getenv("SNAPSHOT_S3_ENDPOINT"), os.Getenv("SNAPSHOT_S3_REGION"), os.Getenv("SNAPSHOT_S3_BUCKET"),
+				string(secret.Data[s3.AccessKeyIDKey]), string(secret.Data[s3.SecretAccessKeyKey])),
+			prefix: path.Join(r.Namespace, r.Execution.Spec.ExecutionName),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown snapshot store %q", kind)
+	}
+}
+
+// secretStore keeps snapshots in secrets owned by the state
+type secretStore struct {
+	runner *Runner
+}
+
+func (s *secretStore) name(execution string) string {
+	return "snapshot-" + execution
+}
+
+func (s *secretStore) save(ctx context.Context, name string, data []byte) error {
+	secret, err := s.runner.getSecret(name)
+	if err == nil {
+		// a retried job saves the snapshot again
+		secret = secret.DeepCopy()
+		secret.Data = map[string][]byte{snapshotKey: data}
+		_, err = s.runner.secrets.Update(secret)
+		return err
+	} else if !k8sError.IsNotFound(err) {
+		return err
+	}
+
+	_, err = s.runner.secrets.Create(&coreV1.Secret{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:            name,
+			Namespace:       s.runner.Execution.Namespace,
+			OwnerReferences: s.runner.Execution.OwnerReferences,
+			Labels:          map[string]string{"state": s.runner.Execution.Spec.ExecutionName},
+		},
+		Data: map[string][]byte{
+			snapshotKey: data,
+		},
+	})
+	return err
+}
+
+func (s *secretStore) load(ctx context.Context, name string) ([]byte, error) {
+	secret, err := s.runner.getSecret(name)
+	if err != nil {
+		return nil, err
+	}
+	return secret.Data[snapshotKey], nil
+}
+
+func (s *secretStore) delete(ctx context.Context, name string) error {
+	err := s.runner.secrets.Delete(s.runner.Execution.Namespace, name, &metaV1.DeleteOptions{})
+	if k8sError.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// s3Store keeps snapshots as <namespace>/<state>/<execution>.tfstate objects
+type s3Store struct {
+	client *s3.Client
+	prefix string
+}
+
+func (s *s3Store) name(execution string) string {
+	return path.Join(s.prefix, execution+".tfstate")
+}
+
+func (s *s3Store) save(ctx context.Context, name string, data []byte) error {
+	return s.client.Put(ctx, name, data)
+}
+
+func (s *s3Store) load(ctx context.Context, name string) ([]byte, error) {
+	return s.client.Get(ctx, name)
+}
+
+func (s *s3Store) delete(ctx context.Context, name string) error {
+	return s.client.Delete(ctx, name)
+}
@@ -0,0 +1,22 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextSerial(t *testing.T) {
+	snapshot := []byte(`{"version":4,"serial":3,"lineage":"6f1c","resources":[{"type":"aws_vpc","name":"main"}]}`)
+
+	restored, err := nextSerial(snapshot, stateFile{Serial: 7, Lineage: "6f1c"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"version":4,"serial":8,"lineage":"6f1c","resources":[{"type":"aws_vpc","name":"main"}]}`, string(restored))
+
+	restored, err = nextSerial(snapshot, stateFile{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"version":4,"serial":1,"lineage":"6f1c","resources":[{"type":"aws_vpc","name":"main"}]}`, string(restored))
+
+	_, err = nextSerial(snapshot, stateFile{Serial: 7, Lineage: "9a2b"})
+	assert.EqualError(t, err, "lineage 6f1c does not match the current state's lineage 9a2b")
+}
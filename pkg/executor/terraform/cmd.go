@@ -12,7 +12,26 @@ import (
 )
 
 func terraform(ctx context.Context, env []string, args ...string) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "terraform", args...)
+	out, err := quiet(ctx, env, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var output []string
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		line := s.Text()
+		fmt.Println(line)
+		output = append(output, line)
+	}
+
+	return output, s.Err()
+}
+
+// quiet runs terraform and returns its output without printing it, for output such as the
+// state that must not end up in the job logs
+func quiet(ctx context.Context, env []string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, binary, args...)
 	cmd.Env = append(os.Environ(), env...)
 
 	var (
@@ -25,14 +44,5 @@ func terraform(ctx context.Context, env []string, args ...string) ([]string, err
 	if err != nil {
 		return nil, errors.Wrap(err, errOut.String())
 	}
-
-	var output []string
-	s := bufio.NewScanner(&out)
-	for s.Scan() {
-		line := s.Text()
-		fmt.Println(line)
-		output = append(output, line)
-	}
-
-	return output, s.Err()
+	return out.Bytes(), nil
 }
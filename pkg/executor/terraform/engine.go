@@ -0,0 +1,49 @@
+package terraform
+
+import "fmt"
+
+// Engine is a binary compatible with the terraform CLI. OpenTofu accepts the same commands
+// and flags, only the binary and where its releases are published differ.
+type Engine struct {
+	// Name of the binary and the prefix of its release archives
+	Name string
+	// DefaultMirror is where releases are downloaded from when no mirror is set
+	DefaultMirror string
+	// MirrorEnv is the environment variable that overrides DefaultMirror
+	MirrorEnv string
+	// TagPrefix is put in front of the version in release urls
+	TagPrefix string
+}
+
+var (
+	Terraform = Engine{
+		Name:          "terraform",
+		DefaultMirror: "https://releases.hashicorp.com/terraform",
+		MirrorEnv:     "TERRAFORM_MIRROR",
+	}
+	Tofu = Engine{
+		Name:          "tofu",
+		DefaultMirror: "https://github.com/opentofu/opentofu/releases/download",
+		MirrorEnv:     "TOFU_MIRROR",
+		TagPrefix:     "v",
+	}
+
+	binary = Terraform.Name
+)
+
+// GetEngine returns the engine by name, terraform when name is empty
+func GetEngine(name string) (Engine, error) {
+	switch name {
+	case "", Terraform.Name:
+		return Terraform, nil
+	case Tofu.Name:
+		return Tofu, nil
+	default:
+		return Engine{}, fmt.Errorf("unsupported engine %q, use %s or %s", name, Terraform.Name, Tofu.Name)
+	}
+}
+
+// SetBinary changes the binary commands are run with
+func SetBinary(path string) {
+	binary = path
+}
@@ -0,0 +1,75 @@
+package terraform
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEngine puts a script named name on the PATH that echoes its args, "output" prints
+// json like terraform does and "fail" exits with an error
+func fakeEngine(t *testing.T, name string) func() {
+	dir, err := ioutil.TempDir("", "fake-engine")
+	require.NoError(t, err)
+
+	script := `#!/bin/sh
+case "$1" in
+output) echo '{"ip":{"value":"127.0.0.1"}}' ;;
+fail) echo "boom" >&2; exit 1 ;;
+*) echo "` + name + ` $*" ;;
+esac
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755))
+
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	return func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+		SetBinary(Terraform.Name)
+	}
+}
+
+func TestEngine(t *testing.T) {
+	for _, name := range []string{"", "terraform", "tofu"} {
+		engine, err := GetEngine(name)
+		require.NoError(t, err)
+
+		cleanup := fakeEngine(t, engine.Name)
+		SetBinary(engine.Name)
+
+		out, err := Init()
+		require.NoError(t, err)
+		assert.Equal(t, engine.Name+" init -input=false\n", out)
+
+		out, err = SelectWorkspace("dev")
+		require.NoError(t, err)
+		assert.Equal(t, engine.Name+" workspace select dev\n", out)
+
+		out, err = Plan(true)
+		require.NoError(t, err)
+		assert.Equal(t, engine.Name+" plan -input=false -out=tfplan -destroy\n", out)
+
+		out, err = Apply()
+		require.NoError(t, err)
+		assert.Equal(t, engine.Name+" apply -input=false -auto-approve tfplan\n", out)
+
+		out, err = Output()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"ip":{"value":"127.0.0.1"}}`, out)
+
+		_, err = terraform(context.Background(), nil, "fail")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "boom")
+
+		cleanup()
+	}
+
+	_, err := GetEngine("pulumi")
+	assert.Error(t, err)
+}
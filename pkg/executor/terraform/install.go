@@ -0,0 +1,162 @@
+package terraform
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var versionPattern = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.]+)?$`)
+
+// Install downloads the release version of the engine from mirror into cacheDir, verified
+// against the release's SHA256SUMS, and returns the path to the binary. Releases already
+// in cacheDir are not downloaded again.
+func (e Engine) Install(ctx context.Context, mirror, version, cacheDir string) (string, error) {
+	if !versionPattern.MatchString(version) {
+		return "", fmt.Errorf("invalid %s version %q", e.Name, version)
+	}
+	if mirror == "" {
+		mirror = e.DefaultMirror
+	}
+	release := fmt.Sprintf("%s/%s%s", strings.TrimSuffix(mirror, "/"), e.TagPrefix, version)
+
+	dir := filepath.Join(cacheDir, e.Name, version)
+	path := filepath.Join(dir, e.Name)
+	if _, err := os.Stat(path); err == nil {
+		logrus.Infof("using cached %s %s", e.Name, version)
+		return path, nil
+	}
+
+	zipName := fmt.Sprintf("%s_%s_%s_%s.zip", e.Name, version, runtime.GOOS, runtime.GOARCH)
+	sums, err := checksums(ctx, fmt.Sprintf("%s/%s_%s_SHA256SUMS", release, e.Name, version))
+	if err != nil {
+		return "", err
+	}
+	sum, ok := sums[zipName]
+	if !ok {
+		return "", fmt.Errorf("no checksum for %s in the SHA256SUMS of %s %s", zipName, e.Name, version)
+	}
+
+	logrus.Infof("downloading %s %s from %s", e.Name, version, mirror)
+	archive, err := ioutil.TempFile("", "terraform-release")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	if err := download(ctx, fmt.Sprintf("%s/%s", release, zipName), sum, archive); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	// extract next to the final path and rename, so concurrent jobs sharing the cache never
+	// see a partial binary
+	tmp, err := ioutil.TempFile(dir, "."+e.Name)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := extractBinary(archive.Name(), e.Name, tmp); err != nil {
+		return "", err
+	}
+	if err := tmp.Chmod(0755); err != nil {
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	return path, os.Rename(tmp.Name(), path)
+}
+
+// checksums parses a SHA256SUMS file into a map of file name to hex encoded sha256
+func checksums(ctx context.Context, url string) (map[string]string, error) {
+	resp, err := get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	sums := map[string]string{}
+	s := bufio.NewScanner(resp.Body)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 2 {
+			sums[fields[1]] = fields[0]
+		}
+	}
+	return sums, s.Err()
+}
+
+func download(ctx context.Context, url, sum string, w io.Writer) error {
+	resp, err := get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	digest := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, digest), resp.Body); err != nil {
+		return errors.Wrapf(err, "downloading %s", url)
+	}
+	if actual := hex.EncodeToString(digest.Sum(nil)); actual != sum {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", url, sum, actual)
+	}
+	return nil
+}
+
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: unexpected status %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+func extractBinary(archive, name string, w io.Writer) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		_, err = io.Copy(w, r)
+		return err
+	}
+	return fmt.Errorf("%s binary not found in release archive", name)
+}
@@ -0,0 +1,131 @@
+package terraform
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func release(t *testing.T, binary string) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create("terraform")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(binary))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestInstall(t *testing.T) {
+	archive := release(t, "#!/bin/sh\necho terraform\n")
+	sum := sha256.Sum256(archive)
+	zipName := fmt.Sprintf("terraform_1.0.11_%s_%s.zip", runtime.GOOS, runtime.GOARCH)
+	sums := fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), zipName)
+
+	requests := 0
+	mirror := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		switch req.URL.Path {
+		case "/1.0.11/terraform_1.0.11_SHA256SUMS":
+			rw.Write([]byte(sums))
+		case "/1.0.11/" + zipName:
+			rw.Write(archive)
+		case "/1.0.12/terraform_1.0.12_SHA256SUMS":
+			rw.Write([]byte(sums))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mirror.Close()
+
+	cacheDir, err := ioutil.TempDir("", "install-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	path, err := Terraform.Install(context.Background(), mirror.URL, "1.0.11", cacheDir)
+	require.NoError(t, err)
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho terraform\n", string(content))
+	assert.Equal(t, 2, requests)
+
+	// cached
+	_, err = Terraform.Install(context.Background(), mirror.URL, "1.0.11", cacheDir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+
+	// the SHA256SUMS of 1.0.12 have no entry for its archive
+	_, err = Terraform.Install(context.Background(), mirror.URL, "1.0.12", cacheDir)
+	assert.Error(t, err)
+
+	_, err = Terraform.Install(context.Background(), mirror.URL, "../1.0.11", cacheDir)
+	assert.Error(t, err)
+}
+
+func TestInstallChecksumMismatch(t *testing.T) {
+	zipName := fmt.Sprintf("terraform_1.0.11_%s_%s.zip", runtime.GOOS, runtime.GOARCH)
+	mirror := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/1.0.11/terraform_1.0.11_SHA256SUMS":
+			fmt.Fprintf(rw, "%064d  %s\n", 0, zipName)
+		default:
+			rw.Write(release(t, "tampered"))
+		}
+	}))
+	defer mirror.Close()
+
+	cacheDir, err := ioutil.TempDir("", "install-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	_, err = Terraform.Install(context.Background(), mirror.URL, "1.0.11", cacheDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch")
+}
+
+func TestInstallTofu(t *testing.T) {
+	archive := &bytes.Buffer{}
+	zw := zip.NewWriter(archive)
+	w, err := zw.Create("tofu")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("tofu"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	sum := sha256.Sum256(archive.Bytes())
+	zipName := fmt.Sprintf("tofu_1.6.0_%s_%s.zip", runtime.GOOS, runtime.GOARCH)
+
+	mirror := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1.6.0/tofu_1.6.0_SHA256SUMS":
+			fmt.Fprintf(rw, "%s  %s\n", hex.EncodeToString(sum[:]), zipName)
+		case "/v1.6.0/" + zipName:
+			rw.Write(archive.Bytes())
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mirror.Close()
+
+	cacheDir, err := ioutil.TempDir("", "install-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	path, err := Tofu.Install(context.Background(), mirror.URL, "1.6.0", cacheDir)
+	require.NoError(t, err)
+	assert.Equal(t, "tofu", filepath.Base(path))
+}
@@ -0,0 +1,78 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// PlanFile is the file Plan saves the plan to and Apply applies
+const PlanFile = "tfplan"
+
+var (
+	colorPattern   = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	summaryPattern = regexp.MustCompile(`(?m)^(Plan: \d+ to \w+.*|No changes\..*)$`)
+)
+
+// JSONPlan is the part of the JSON plan representation the executor inspects, see
+// https://www.terraform.io/docs/internals/json-format.html
+type JSONPlan struct {
+	ResourceChanges []ResourceChange `json:"resource_changes"`
+}
+
+type ResourceChange struct {
+	Address string `json:"address"`
+	Mode    string `json:"mode"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Change  Change `json:"change"`
+}
+
+type Change struct {
+	// Actions are no-op, create, read, update or delete, a replace is delete and create in
+	// either order
+	Actions []string `json:"actions"`
+}
+
+// ShowPlan runs 'terraform show -json' on the saved plan. The plan can hold sensitive values,
+// so it is not printed.
+func ShowPlan() ([]byte, error) {
+	return quiet(context.Background(), os.Environ(), "show", "-json", PlanFile)
+}
+
+// ParsePlan parses the JSON output of ShowPlan
+func ParsePlan(data []byte) (*JSONPlan, error) {
+	plan := &JSONPlan{}
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, errors.Wrap(err, "parsing plan")
+	}
+	return plan, nil
+}
+
+// PlanSummary returns the summary line of the output of Plan, e.g. "Plan: 1 to add, 0 to
+// change, 0 to destroy.", empty if there is none
+func PlanSummary(out string) string {
+	return summaryPattern.FindString(colorPattern.ReplaceAllString(out, ""))
+}
+
+// Creates is true if the change creates the resource, including replacing it
+func (c Change) Creates() bool {
+	return c.has("create")
+}
+
+// Deletes is true if the change deletes the resource, including replacing it
+func (c Change) Deletes() bool {
+	return c.has("delete")
+}
+
+func (c Change) has(action string) bool {
+	for _, a := range c.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanSummary(t *testing.T) {
+	out := "\x1b[1m  # aws_instance.web\x1b[0m will be created\n" +
+		"\x1b[0m\x1b[1mPlan:\x1b[0m 1 to add, 0 to change, 0 to destroy.\n" +
+		"\nSaved the plan to: tfplan\n"
+	assert.Equal(t, "Plan: 1 to add, 0 to change, 0 to destroy.", PlanSummary(out))
+
+	assert.Equal(t, "No changes. Your infrastructure matches the configuration.",
+		PlanSummary("\x1b[32m\x1b[1mNo changes.\x1b[0m Your infrastructure matches the configuration.\n"))
+	assert.Empty(t, PlanSummary("Error: Invalid reference\n"))
+}
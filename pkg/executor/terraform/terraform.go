@@ -10,7 +10,7 @@ import (
 const newLine = "\n"
 
 func Apply() (string, error) {
-	output, err := terraform(context.Background(), os.Environ(), "apply", "-input=false", "-auto-approve", "tfplan")
+	output, err := terraform(context.Background(), os.Environ(), "apply", "-input=false", "-auto-approve", PlanFile)
 	if err != nil {
 		return "", err
 	}
@@ -27,8 +27,81 @@ func Destroy() (string, error) {
 	return combineOutput(output), nil
 }
 
-func Init() (string, error) {
-	output, err := terraform(context.Background(), os.Environ(), "init", "-input=false")
+// Init runs 'terraform init' with any extra args, such as -lockfile=readonly
+func Init(args ...string) (string, error) {
+	output, err := terraform(context.Background(), os.Environ(), append([]string{"init", "-input=false"}, args...)...)
+	if err != nil {
+		return "", err
+	}
+
+	return combineOutput(output), nil
+}
+
+// SelectWorkspace runs 'terraform workspace select' to switch to the workspace, and
+// 'terraform workspace new' when it does not exist. select -or-create would do both but
+// needs terraform 1.4.
+func SelectWorkspace(name string) (string, error) {
+	output, err := terraform(context.Background(), os.Environ(), "workspace", "select", name)
+	if err == nil {
+		return combineOutput(output), nil
+	}
+
+	output, err = terraform(context.Background(), os.Environ(), "workspace", "new", name)
+	if err != nil {
+		return "", err
+	}
+
+	return combineOutput(output), nil
+}
+
+// Import runs 'terraform import' to adopt the existing resource id at address
+func Import(address, id string) (string, error) {
+	output, err := terraform(context.Background(), os.Environ(), "import", "-input=false", address, id)
+	if err != nil {
+		return "", err
+	}
+
+	return combineOutput(output), nil
+}
+
+// StateMove runs 'terraform state mv' to move the resource at from to the address to
+func StateMove(from, to string) (string, error) {
+	output, err := terraform(context.Background(), os.Environ(), "state", "mv", from, to)
+	if err != nil {
+		return "", err
+	}
+
+	return combineOutput(output), nil
+}
+
+// StateRemove runs 'terraform state rm' to drop the addresses from the state
+func StateRemove(addresses ...string) (string, error) {
+	output, err := terraform(context.Background(), os.Environ(), append([]string{"state", "rm"}, addresses...)...)
+	if err != nil {
+		return "", err
+	}
+
+	return combineOutput(output), nil
+}
+
+// ForceUnlock runs 'terraform force-unlock' to release the lock id
+func ForceUnlock(id string) (string, error) {
+	output, err := terraform(context.Background(), os.Environ(), "force-unlock", "-force", id)
+	if err != nil {
+		return "", err
+	}
+
+	return combineOutput(output), nil
+}
+
+// StatePull runs 'terraform state pull' and returns the state, empty if there is none yet
+func StatePull() ([]byte, error) {
+	return quiet(context.Background(), os.Environ(), "state", "pull")
+}
+
+// StatePush runs 'terraform state push' to replace the state with the state file at path
+func StatePush(path string) (string, error) {
+	output, err := terraform(context.Background(), os.Environ(), "state", "push", path)
 	if err != nil {
 		return "", err
 	}
@@ -46,12 +119,14 @@ func Output() (string, error) {
 	return combineOutput(output), nil
 }
 
-// Plan runs 'terraform plan' with the destroy flag controlling the play type
-func Plan(destroy bool) (string, error) {
-	args := []string{"plan", "-input=false", "-out=tfplan"}
+// Plan runs 'terraform plan' with the destroy flag controlling the play type, extra args
+// such as -target are appended
+func Plan(destroy bool, extra ...string) (string, error) {
+	args := []string{"plan", "-input=false", "-out=" + PlanFile}
 	if destroy {
 		args = append(args, "-destroy")
 	}
+	args = append(args, extra...)
 
 	output, err := terraform(context.Background(), os.Environ(), args...)
 	if err != nil {
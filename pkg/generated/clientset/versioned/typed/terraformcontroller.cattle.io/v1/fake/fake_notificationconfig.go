@@ -0,0 +1,130 @@
+/*
+Copyright 2019 Rancher Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	terraformcontrollercattleiov1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeNotificationConfigs implements NotificationConfigInterface
+type FakeNotificationConfigs struct {
+	Fake *FakeTerraformcontrollerV1
+	ns   string
+}
+
+var notificationConfigsResource = schema.GroupVersionResource{Group: "terraformcontroller.cattle.io", Version: "v1", Resource: "notificationconfigs"}
+
+var notificationConfigsKind = schema.GroupVersionKind{Group: "terraformcontroller.cattle.io", Version: "v1", Kind: "NotificationConfig"}
+
+// Get takes name of the notificationConfig, and returns the corresponding notificationConfig object, and an error if there is any.
+func (c *FakeNotificationConfigs) Get(ctx context.Context, name string, options v1.GetOptions) (result *terraformcontrollercattleiov1.NotificationConfig, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(notificationConfigsResource, c.ns, name), &terraformcontrollercattleiov1.NotificationConfig{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*terraformcontrollercattleiov1.NotificationConfig), err
+}
+
+// List takes label and field selectors, and returns the list of NotificationConfigs that match those selectors.
+func (c *FakeNotificationConfigs) List(ctx context.Context, opts v1.ListOptions) (result *terraformcontrollercattleiov1.NotificationConfigList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(notificationConfigsResource, notificationConfigsKind, c.ns, opts), &terraformcontrollercattleiov1.NotificationConfigList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &terraformcontrollercattleiov1.NotificationConfigList{ListMeta: obj.(*terraformcontrollercattleiov1.NotificationConfigList).ListMeta}
+	for _, item := range obj.(*terraformcontrollercattleiov1.NotificationConfigList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested notificationConfigs.
+func (c *FakeNotificationConfigs) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(notificationConfigsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a notificationConfig and creates it.  Returns the server's representation of the notificationConfig, and an error, if there is any.
+func (c *FakeNotificationConfigs) Create(ctx context.Context, notificationConfig *terraformcontrollercattleiov1.NotificationConfig, opts v1.CreateOptions) (result *terraformcontrollercattleiov1.NotificationConfig, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(notificationConfigsResource, c.ns, notificationConfig), &terraformcontrollercattleiov1.NotificationConfig{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*terraformcontrollercattleiov1.NotificationConfig), err
+}
+
+// Update takes the representation of a notificationConfig and updates it. Returns the server's representation of the notificationConfig, and an error, if there is any.
+func (c *FakeNotificationConfigs) Update(ctx context.Context, notificationConfig *terraformcontrollercattleiov1.NotificationConfig, opts v1.UpdateOptions) (result *terraformcontrollercattleiov1.NotificationConfig, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(notificationConfigsResource, c.ns, notificationConfig), &terraformcontrollercattleiov1.NotificationConfig{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*terraformcontrollercattleiov1.NotificationConfig), err
+}
+
+// Delete takes name of the notificationConfig and deletes it. Returns an error if one occurs.
+func (c *FakeNotificationConfigs) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(notificationConfigsResource, c.ns, name), &terraformcontrollercattleiov1.NotificationConfig{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeNotificationConfigs) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(notificationConfigsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &terraformcontrollercattleiov1.NotificationConfigList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched notificationConfig.
+func (c *FakeNotificationConfigs) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *terraformcontrollercattleiov1.NotificationConfig, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(notificationConfigsResource, c.ns, name, pt, data, subresources...), &terraformcontrollercattleiov1.NotificationConfig{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*terraformcontrollercattleiov1.NotificationConfig), err
+}
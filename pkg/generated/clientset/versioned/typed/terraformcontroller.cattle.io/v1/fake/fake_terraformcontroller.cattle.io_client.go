@@ -36,6 +36,14 @@ func (c *FakeTerraformcontrollerV1) Modules(namespace string) v1.ModuleInterface
 	return &FakeModules{c, namespace}
 }
 
+func (c *FakeTerraformcontrollerV1) NotificationConfigs(namespace string) v1.NotificationConfigInterface {
+	return &FakeNotificationConfigs{c, namespace}
+}
+
+func (c *FakeTerraformcontrollerV1) Policies(namespace string) v1.PolicyInterface {
+	return &FakePolicies{c, namespace}
+}
+
 func (c *FakeTerraformcontrollerV1) States(namespace string) v1.StateInterface {
 	return &FakeStates{c, namespace}
 }
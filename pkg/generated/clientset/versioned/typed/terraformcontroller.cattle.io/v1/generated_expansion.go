@@ -22,4 +22,8 @@ type ExecutionExpansion interface{}
 
 type ModuleExpansion interface{}
 
+type NotificationConfigExpansion interface{}
+
+type PolicyExpansion interface{}
+
 type StateExpansion interface{}
@@ -0,0 +1,178 @@
+/*
+Copyright 2019 Rancher Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	scheme "github.com/rancher/terraform-controller/pkg/generated/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// NotificationConfigsGetter has a method to return a NotificationConfigInterface.
+// A group's client should implement this interface.
+type NotificationConfigsGetter interface {
+	NotificationConfigs(namespace string) NotificationConfigInterface
+}
+
+// NotificationConfigInterface has methods to work with NotificationConfig resources.
+type NotificationConfigInterface interface {
+	Create(ctx context.Context, notificationConfig *v1.NotificationConfig, opts metav1.CreateOptions) (*v1.NotificationConfig, error)
+	Update(ctx context.Context, notificationConfig *v1.NotificationConfig, opts metav1.UpdateOptions) (*v1.NotificationConfig, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.NotificationConfig, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.NotificationConfigList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.NotificationConfig, err error)
+	NotificationConfigExpansion
+}
+
+// notificationConfigs implements NotificationConfigInterface
+type notificationConfigs struct {
+	client rest.Interface
+	ns     string
+}
+
+// newNotificationConfigs returns a NotificationConfigs
+func newNotificationConfigs(c *TerraformcontrollerV1Client, namespace string) *notificationConfigs {
+	return &notificationConfigs{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the notificationConfig, and returns the corresponding notificationConfig object, and an error if there is any.
+func (c *notificationConfigs) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.NotificationConfig, err error) {
+	result = &v1.NotificationConfig{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("notificationconfigs").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of NotificationConfigs that match those selectors.
+func (c *notificationConfigs) List(ctx context.Context, opts metav1.ListOptions) (result *v1.NotificationConfigList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.NotificationConfigList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("notificationconfigs").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested notificationConfigs.
+func (c *notificationConfigs) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("notificationconfigs").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a notificationConfig and creates it.  Returns the server's representation of the notificationConfig, and an error, if there is any.
+func (c *notificationConfigs) Create(ctx context.Context, notificationConfig *v1.NotificationConfig, opts metav1.CreateOptions) (result *v1.NotificationConfig, err error) {
+	result = &v1.NotificationConfig{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("notificationconfigs").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(notificationConfig).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a notificationConfig and updates it. Returns the server's representation of the notificationConfig, and an error, if there is any.
+func (c *notificationConfigs) Update(ctx context.Context, notificationConfig *v1.NotificationConfig, opts metav1.UpdateOptions) (result *v1.NotificationConfig, err error) {
+	result = &v1.NotificationConfig{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("notificationconfigs").
+		Name(notificationConfig.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(notificationConfig).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the notificationConfig and deletes it. Returns an error if one occurs.
+func (c *notificationConfigs) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("notificationconfigs").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *notificationConfigs) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("notificationconfigs").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched notificationConfig.
+func (c *notificationConfigs) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.NotificationConfig, err error) {
+	result = &v1.NotificationConfig{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("notificationconfigs").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -28,6 +28,8 @@ type TerraformcontrollerV1Interface interface {
 	RESTClient() rest.Interface
 	ExecutionsGetter
 	ModulesGetter
+	NotificationConfigsGetter
+	PoliciesGetter
 	StatesGetter
 }
 
@@ -44,6 +46,14 @@ func (c *TerraformcontrollerV1Client) Modules(namespace string) ModuleInterface
 	return newModules(c, namespace)
 }
 
+func (c *TerraformcontrollerV1Client) NotificationConfigs(namespace string) NotificationConfigInterface {
+	return newNotificationConfigs(c, namespace)
+}
+
+func (c *TerraformcontrollerV1Client) Policies(namespace string) PolicyInterface {
+	return newPolicies(c, namespace)
+}
+
 func (c *TerraformcontrollerV1Client) States(namespace string) StateInterface {
 	return newStates(c, namespace)
 }
@@ -32,6 +32,8 @@ func init() {
 type Interface interface {
 	Execution() ExecutionController
 	Module() ModuleController
+	NotificationConfig() NotificationConfigController
+	Policy() PolicyController
 	State() StateController
 }
 
@@ -51,6 +53,12 @@ func (c *version) Execution() ExecutionController {
 func (c *version) Module() ModuleController {
 	return NewModuleController(schema.GroupVersionKind{Group: "terraformcontroller.cattle.io", Version: "v1", Kind: "Module"}, "modules", true, c.controllerFactory)
 }
+func (c *version) NotificationConfig() NotificationConfigController {
+	return NewNotificationConfigController(schema.GroupVersionKind{Group: "terraformcontroller.cattle.io", Version: "v1", Kind: "NotificationConfig"}, "notificationconfigs", true, c.controllerFactory)
+}
+func (c *version) Policy() PolicyController {
+	return NewPolicyController(schema.GroupVersionKind{Group: "terraformcontroller.cattle.io", Version: "v1", Kind: "Policy"}, "policies", true, c.controllerFactory)
+}
 func (c *version) State() StateController {
 	return NewStateController(schema.GroupVersionKind{Group: "terraformcontroller.cattle.io", Version: "v1", Kind: "State"}, "states", true, c.controllerFactory)
 }
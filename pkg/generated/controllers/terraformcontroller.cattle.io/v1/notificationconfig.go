@@ -0,0 +1,256 @@
+/*
+Copyright 2019 Rancher Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/lasso/pkg/controller"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/wrangler/pkg/generic"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+type NotificationConfigHandler func(string, *v1.NotificationConfig) (*v1.NotificationConfig, error)
+
+type NotificationConfigController interface {
+	generic.ControllerMeta
+	NotificationConfigClient
+
+	OnChange(ctx context.Context, name string, sync NotificationConfigHandler)
+	OnRemove(ctx context.Context, name string, sync NotificationConfigHandler)
+	Enqueue(namespace, name string)
+	EnqueueAfter(namespace, name string, duration time.Duration)
+
+	Cache() NotificationConfigCache
+}
+
+type NotificationConfigClient interface {
+	Create(*v1.NotificationConfig) (*v1.NotificationConfig, error)
+	Update(*v1.NotificationConfig) (*v1.NotificationConfig, error)
+
+	Delete(namespace, name string, options *metav1.DeleteOptions) error
+	Get(namespace, name string, options metav1.GetOptions) (*v1.NotificationConfig, error)
+	List(namespace string, opts metav1.ListOptions) (*v1.NotificationConfigList, error)
+	Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.NotificationConfig, err error)
+}
+
+type NotificationConfigCache interface {
+	Get(namespace, name string) (*v1.NotificationConfig, error)
+	List(namespace string, selector labels.Selector) ([]*v1.NotificationConfig, error)
+
+	AddIndexer(indexName string, indexer NotificationConfigIndexer)
+	GetByIndex(indexName, key string) ([]*v1.NotificationConfig, error)
+}
+
+type NotificationConfigIndexer func(obj *v1.NotificationConfig) ([]string, error)
+
+type notificationConfigController struct {
+	controller    controller.SharedController
+	client        *client.Client
+	gvk           schema.GroupVersionKind
+	groupResource schema.GroupResource
+}
+
+func NewNotificationConfigController(gvk schema.GroupVersionKind, resource string, namespaced bool, controller controller.SharedControllerFactory) NotificationConfigController {
+	c := controller.ForResourceKind(gvk.GroupVersion().WithResource(resource), gvk.Kind, namespaced)
+	return &notificationConfigController{
+		controller: c,
+		client:     c.Client(),
+		gvk:        gvk,
+		groupResource: schema.GroupResource{
+			Group:    gvk.Group,
+			Resource: resource,
+		},
+	}
+}
+
+func FromNotificationConfigHandlerToHandler(sync NotificationConfigHandler) generic.Handler {
+	return func(key string, obj runtime.Object) (ret runtime.Object, err error) {
+		var v *v1.NotificationConfig
+		if obj == nil {
+			v, err = sync(key, nil)
+		} else {
+			v, err = sync(key, obj.(*v1.NotificationConfig))
+		}
+		if v == nil {
+			return nil, err
+		}
+		return v, err
+	}
+}
+
+func (c *notificationConfigController) Updater() generic.Updater {
+	return func(obj runtime.Object) (runtime.Object, error) {
+		newObj, err := c.Update(obj.(*v1.NotificationConfig))
+		if newObj == nil {
+			return nil, err
+		}
+		return newObj, err
+	}
+}
+
+func UpdateNotificationConfigDeepCopyOnChange(client NotificationConfigClient, obj *v1.NotificationConfig, handler func(obj *v1.NotificationConfig) (*v1.NotificationConfig, error)) (*v1.NotificationConfig, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	copyObj := obj.DeepCopy()
+	newObj, err := handler(copyObj)
+	if newObj != nil {
+		copyObj = newObj
+	}
+	if obj.ResourceVersion == copyObj.ResourceVersion && !equality.Semantic.DeepEqual(obj, copyObj) {
+		return client.Update(copyObj)
+	}
+
+	return copyObj, err
+}
+
+func (c *notificationConfigController) AddGenericHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.controller.RegisterHandler(ctx, name, controller.SharedControllerHandlerFunc(handler))
+}
+
+func (c *notificationConfigController) AddGenericRemoveHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), handler))
+}
+
+func (c *notificationConfigController) OnChange(ctx context.Context, name string, sync NotificationConfigHandler) {
+	c.AddGenericHandler(ctx, name, FromNotificationConfigHandlerToHandler(sync))
+}
+
+func (c *notificationConfigController) OnRemove(ctx context.Context, name string, sync NotificationConfigHandler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), FromNotificationConfigHandlerToHandler(sync)))
+}
+
+func (c *notificationConfigController) Enqueue(namespace, name string) {
+	c.controller.Enqueue(namespace, name)
+}
+
+func (c *notificationConfigController) EnqueueAfter(namespace, name string, duration time.Duration) {
+	c.controller.EnqueueAfter(namespace, name, duration)
+}
+
+func (c *notificationConfigController) Informer() cache.SharedIndexInformer {
+	return c.controller.Informer()
+}
+
+func (c *notificationConfigController) GroupVersionKind() schema.GroupVersionKind {
+	return c.gvk
+}
+
+func (c *notificationConfigController) Cache() NotificationConfigCache {
+	return &notificationConfigCache{
+		indexer:  c.Informer().GetIndexer(),
+		resource: c.groupResource,
+	}
+}
+
+func (c *notificationConfigController) Create(obj *v1.NotificationConfig) (*v1.NotificationConfig, error) {
+	result := &v1.NotificationConfig{}
+	return result, c.client.Create(context.TODO(), obj.Namespace, obj, result, metav1.CreateOptions{})
+}
+
+func (c *notificationConfigController) Update(obj *v1.NotificationConfig) (*v1.NotificationConfig, error) {
+	result := &v1.NotificationConfig{}
+	return result, c.client.Update(context.TODO(), obj.Namespace, obj, result, metav1.UpdateOptions{})
+}
+
+func (c *notificationConfigController) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	if options == nil {
+		options = &metav1.DeleteOptions{}
+	}
+	return c.client.Delete(context.TODO(), namespace, name, *options)
+}
+
+func (c *notificationConfigController) Get(namespace, name string, options metav1.GetOptions) (*v1.NotificationConfig, error) {
+	result := &v1.NotificationConfig{}
+	return result, c.client.Get(context.TODO(), namespace, name, result, options)
+}
+
+func (c *notificationConfigController) List(namespace string, opts metav1.ListOptions) (*v1.NotificationConfigList, error) {
+	result := &v1.NotificationConfigList{}
+	return result, c.client.List(context.TODO(), namespace, result, opts)
+}
+
+func (c *notificationConfigController) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.client.Watch(context.TODO(), namespace, opts)
+}
+
+func (c *notificationConfigController) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (*v1.NotificationConfig, error) {
+	result := &v1.NotificationConfig{}
+	return result, c.client.Patch(context.TODO(), namespace, name, pt, data, result, metav1.PatchOptions{}, subresources...)
+}
+
+type notificationConfigCache struct {
+	indexer  cache.Indexer
+	resource schema.GroupResource
+}
+
+func (c *notificationConfigCache) Get(namespace, name string) (*v1.NotificationConfig, error) {
+	obj, exists, err := c.indexer.GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(c.resource, name)
+	}
+	return obj.(*v1.NotificationConfig), nil
+}
+
+func (c *notificationConfigCache) List(namespace string, selector labels.Selector) (ret []*v1.NotificationConfig, err error) {
+
+	err = cache.ListAllByNamespace(c.indexer, namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.NotificationConfig))
+	})
+
+	return ret, err
+}
+
+func (c *notificationConfigCache) AddIndexer(indexName string, indexer NotificationConfigIndexer) {
+	utilruntime.Must(c.indexer.AddIndexers(map[string]cache.IndexFunc{
+		indexName: func(obj interface{}) (strings []string, e error) {
+			return indexer(obj.(*v1.NotificationConfig))
+		},
+	}))
+}
+
+func (c *notificationConfigCache) GetByIndex(indexName, key string) (result []*v1.NotificationConfig, err error) {
+	objs, err := c.indexer.ByIndex(indexName, key)
+	if err != nil {
+		return nil, err
+	}
+	result = make([]*v1.NotificationConfig, 0, len(objs))
+	for _, obj := range objs {
+		result = append(result, obj.(*v1.NotificationConfig))
+	}
+	return result, nil
+}
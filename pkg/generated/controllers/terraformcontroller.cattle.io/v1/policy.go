@@ -0,0 +1,256 @@
+/*
+Copyright 2019 Rancher Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/lasso/pkg/controller"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/wrangler/pkg/generic"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+type PolicyHandler func(string, *v1.Policy) (*v1.Policy, error)
+
+type PolicyController interface {
+	generic.ControllerMeta
+	PolicyClient
+
+	OnChange(ctx context.Context, name string, sync PolicyHandler)
+	OnRemove(ctx context.Context, name string, sync PolicyHandler)
+	Enqueue(namespace, name string)
+	EnqueueAfter(namespace, name string, duration time.Duration)
+
+	Cache() PolicyCache
+}
+
+type PolicyClient interface {
+	Create(*v1.Policy) (*v1.Policy, error)
+	Update(*v1.Policy) (*v1.Policy, error)
+
+	Delete(namespace, name string, options *metav1.DeleteOptions) error
+	Get(namespace, name string, options metav1.GetOptions) (*v1.Policy, error)
+	List(namespace string, opts metav1.ListOptions) (*v1.PolicyList, error)
+	Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.Policy, err error)
+}
+
+type PolicyCache interface {
+	Get(namespace, name string) (*v1.Policy, error)
+	List(namespace string, selector labels.Selector) ([]*v1.Policy, error)
+
+	AddIndexer(indexName string, indexer PolicyIndexer)
+	GetByIndex(indexName, key string) ([]*v1.Policy, error)
+}
+
+type PolicyIndexer func(obj *v1.Policy) ([]string, error)
+
+type policyController struct {
+	controller    controller.SharedController
+	client        *client.Client
+	gvk           schema.GroupVersionKind
+	groupResource schema.GroupResource
+}
+
+func NewPolicyController(gvk schema.GroupVersionKind, resource string, namespaced bool, controller controller.SharedControllerFactory) PolicyController {
+	c := controller.ForResourceKind(gvk.GroupVersion().WithResource(resource), gvk.Kind, namespaced)
+	return &policyController{
+		controller: c,
+		client:     c.Client(),
+		gvk:        gvk,
+		groupResource: schema.GroupResource{
+			Group:    gvk.Group,
+			Resource: resource,
+		},
+	}
+}
+
+func FromPolicyHandlerToHandler(sync PolicyHandler) generic.Handler {
+	return func(key string, obj runtime.Object) (ret runtime.Object, err error) {
+		var v *v1.Policy
+		if obj == nil {
+			v, err = sync(key, nil)
+		} else {
+			v, err = sync(key, obj.(*v1.Policy))
+		}
+		if v == nil {
+			return nil, err
+		}
+		return v, err
+	}
+}
+
+func (c *policyController) Updater() generic.Updater {
+	return func(obj runtime.Object) (runtime.Object, error) {
+		newObj, err := c.Update(obj.(*v1.Policy))
+		if newObj == nil {
+			return nil, err
+		}
+		return newObj, err
+	}
+}
+
+func UpdatePolicyDeepCopyOnChange(client PolicyClient, obj *v1.Policy, handler func(obj *v1.Policy) (*v1.Policy, error)) (*v1.Policy, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	copyObj := obj.DeepCopy()
+	newObj, err := handler(copyObj)
+	if newObj != nil {
+		copyObj = newObj
+	}
+	if obj.ResourceVersion == copyObj.ResourceVersion && !equality.Semantic.DeepEqual(obj, copyObj) {
+		return client.Update(copyObj)
+	}
+
+	return copyObj, err
+}
+
+func (c *policyController) AddGenericHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.controller.RegisterHandler(ctx, name, controller.SharedControllerHandlerFunc(handler))
+}
+
+func (c *policyController) AddGenericRemoveHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), handler))
+}
+
+func (c *policyController) OnChange(ctx context.Context, name string, sync PolicyHandler) {
+	c.AddGenericHandler(ctx, name, FromPolicyHandlerToHandler(sync))
+}
+
+func (c *policyController) OnRemove(ctx context.Context, name string, sync PolicyHandler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), FromPolicyHandlerToHandler(sync)))
+}
+
+func (c *policyController) Enqueue(namespace, name string) {
+	c.controller.Enqueue(namespace, name)
+}
+
+func (c *policyController) EnqueueAfter(namespace, name string, duration time.Duration) {
+	c.controller.EnqueueAfter(namespace, name, duration)
+}
+
+func (c *policyController) Informer() cache.SharedIndexInformer {
+	return c.controller.Informer()
+}
+
+func (c *policyController) GroupVersionKind() schema.GroupVersionKind {
+	return c.gvk
+}
+
+func (c *policyController) Cache() PolicyCache {
+	return &policyCache{
+		indexer:  c.Informer().GetIndexer(),
+		resource: c.groupResource,
+	}
+}
+
+func (c *policyController) Create(obj *v1.Policy) (*v1.Policy, error) {
+	result := &v1.Policy{}
+	return result, c.client.Create(context.TODO(), obj.Namespace, obj, result, metav1.CreateOptions{})
+}
+
+func (c *policyController) Update(obj *v1.Policy) (*v1.Policy, error) {
+	result := &v1.Policy{}
+	return result, c.client.Update(context.TODO(), obj.Namespace, obj, result, metav1.UpdateOptions{})
+}
+
+func (c *policyController) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	if options == nil {
+		options = &metav1.DeleteOptions{}
+	}
+	return c.client.Delete(context.TODO(), namespace, name, *options)
+}
+
+func (c *policyController) Get(namespace, name string, options metav1.GetOptions) (*v1.Policy, error) {
+	result := &v1.Policy{}
+	return result, c.client.Get(context.TODO(), namespace, name, result, options)
+}
+
+func (c *policyController) List(namespace string, opts metav1.ListOptions) (*v1.PolicyList, error) {
+	result := &v1.PolicyList{}
+	return result, c.client.List(context.TODO(), namespace, result, opts)
+}
+
+func (c *policyController) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.client.Watch(context.TODO(), namespace, opts)
+}
+
+func (c *policyController) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (*v1.Policy, error) {
+	result := &v1.Policy{}
+	return result, c.client.Patch(context.TODO(), namespace, name, pt, data, result, metav1.PatchOptions{}, subresources...)
+}
+
+type policyCache struct {
+	indexer  cache.Indexer
+	resource schema.GroupResource
+}
+
+func (c *policyCache) Get(namespace, name string) (*v1.Policy, error) {
+	obj, exists, err := c.indexer.GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(c.resource, name)
+	}
+	return obj.(*v1.Policy), nil
+}
+
+func (c *policyCache) List(namespace string, selector labels.Selector) (ret []*v1.Policy, err error) {
+
+	err = cache.ListAllByNamespace(c.indexer, namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.Policy))
+	})
+
+	return ret, err
+}
+
+func (c *policyCache) AddIndexer(indexName string, indexer PolicyIndexer) {
+	utilruntime.Must(c.indexer.AddIndexers(map[string]cache.IndexFunc{
+		indexName: func(obj interface{}) (strings []string, e error) {
+			return indexer(obj.(*v1.Policy))
+		},
+	}))
+}
+
+func (c *policyCache) GetByIndex(indexName, key string) (result []*v1.Policy, err error) {
+	objs, err := c.indexer.ByIndex(indexName, key)
+	if err != nil {
+		return nil, err
+	}
+	result = make([]*v1.Policy, 0, len(objs))
+	for _, obj := range objs {
+		result = append(result, obj.(*v1.Policy))
+	}
+	return result, nil
+}
@@ -57,6 +57,10 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Terraformcontroller().V1().Executions().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("modules"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Terraformcontroller().V1().Modules().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("notificationconfigs"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Terraformcontroller().V1().NotificationConfigs().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("policies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Terraformcontroller().V1().Policies().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("states"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Terraformcontroller().V1().States().Informer()}, nil
 
@@ -28,6 +28,10 @@ type Interface interface {
 	Executions() ExecutionInformer
 	// Modules returns a ModuleInformer.
 	Modules() ModuleInformer
+	// NotificationConfigs returns a NotificationConfigInformer.
+	NotificationConfigs() NotificationConfigInformer
+	// Policies returns a PolicyInformer.
+	Policies() PolicyInformer
 	// States returns a StateInformer.
 	States() StateInformer
 }
@@ -53,6 +57,16 @@ func (v *version) Modules() ModuleInformer {
 	return &moduleInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// NotificationConfigs returns a NotificationConfigInformer.
+func (v *version) NotificationConfigs() NotificationConfigInformer {
+	return &notificationConfigInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// Policies returns a PolicyInformer.
+func (v *version) Policies() PolicyInformer {
+	return &policyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // States returns a StateInformer.
 func (v *version) States() StateInformer {
 	return &stateInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -0,0 +1,90 @@
+/*
+Copyright 2019 Rancher Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	time "time"
+
+	terraformcontrollercattleiov1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	versioned "github.com/rancher/terraform-controller/pkg/generated/clientset/versioned"
+	internalinterfaces "github.com/rancher/terraform-controller/pkg/generated/informers/externalversions/internalinterfaces"
+	v1 "github.com/rancher/terraform-controller/pkg/generated/listers/terraformcontroller.cattle.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// NotificationConfigInformer provides access to a shared informer and lister for
+// NotificationConfigs.
+type NotificationConfigInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1.NotificationConfigLister
+}
+
+type notificationConfigInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewNotificationConfigInformer constructs a new informer for NotificationConfig type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewNotificationConfigInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredNotificationConfigInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredNotificationConfigInformer constructs a new informer for NotificationConfig type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredNotificationConfigInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.TerraformcontrollerV1().NotificationConfigs(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.TerraformcontrollerV1().NotificationConfigs(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&terraformcontrollercattleiov1.NotificationConfig{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *notificationConfigInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredNotificationConfigInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *notificationConfigInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&terraformcontrollercattleiov1.NotificationConfig{}, f.defaultInformer)
+}
+
+func (f *notificationConfigInformer) Lister() v1.NotificationConfigLister {
+	return v1.NewNotificationConfigLister(f.Informer().GetIndexer())
+}
@@ -34,6 +34,22 @@ type ModuleListerExpansion interface{}
 // ModuleNamespaceLister.
 type ModuleNamespaceListerExpansion interface{}
 
+// NotificationConfigListerExpansion allows custom methods to be added to
+// NotificationConfigLister.
+type NotificationConfigListerExpansion interface{}
+
+// NotificationConfigNamespaceListerExpansion allows custom methods to be added to
+// NotificationConfigNamespaceLister.
+type NotificationConfigNamespaceListerExpansion interface{}
+
+// PolicyListerExpansion allows custom methods to be added to
+// PolicyLister.
+type PolicyListerExpansion interface{}
+
+// PolicyNamespaceListerExpansion allows custom methods to be added to
+// PolicyNamespaceLister.
+type PolicyNamespaceListerExpansion interface{}
+
 // StateListerExpansion allows custom methods to be added to
 // StateLister.
 type StateListerExpansion interface{}
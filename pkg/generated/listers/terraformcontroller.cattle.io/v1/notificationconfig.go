@@ -0,0 +1,94 @@
+/*
+Copyright 2019 Rancher Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NotificationConfigLister helps list NotificationConfigs.
+type NotificationConfigLister interface {
+	// List lists all NotificationConfigs in the indexer.
+	List(selector labels.Selector) (ret []*v1.NotificationConfig, err error)
+	// NotificationConfigs returns an object that can list and get NotificationConfigs.
+	NotificationConfigs(namespace string) NotificationConfigNamespaceLister
+	NotificationConfigListerExpansion
+}
+
+// notificationConfigLister implements the NotificationConfigLister interface.
+type notificationConfigLister struct {
+	indexer cache.Indexer
+}
+
+// NewNotificationConfigLister returns a new NotificationConfigLister.
+func NewNotificationConfigLister(indexer cache.Indexer) NotificationConfigLister {
+	return &notificationConfigLister{indexer: indexer}
+}
+
+// List lists all NotificationConfigs in the indexer.
+func (s *notificationConfigLister) List(selector labels.Selector) (ret []*v1.NotificationConfig, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.NotificationConfig))
+	})
+	return ret, err
+}
+
+// NotificationConfigs returns an object that can list and get NotificationConfigs.
+func (s *notificationConfigLister) NotificationConfigs(namespace string) NotificationConfigNamespaceLister {
+	return notificationConfigNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// NotificationConfigNamespaceLister helps list and get NotificationConfigs.
+type NotificationConfigNamespaceLister interface {
+	// List lists all NotificationConfigs in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1.NotificationConfig, err error)
+	// Get retrieves the NotificationConfig from the indexer for a given namespace and name.
+	Get(name string) (*v1.NotificationConfig, error)
+	NotificationConfigNamespaceListerExpansion
+}
+
+// notificationConfigNamespaceLister implements the NotificationConfigNamespaceLister
+// interface.
+type notificationConfigNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all NotificationConfigs in the indexer for a given namespace.
+func (s notificationConfigNamespaceLister) List(selector labels.Selector) (ret []*v1.NotificationConfig, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.NotificationConfig))
+	})
+	return ret, err
+}
+
+// Get retrieves the NotificationConfig from the indexer for a given namespace and name.
+func (s notificationConfigNamespaceLister) Get(name string) (*v1.NotificationConfig, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("notificationconfig"), name)
+	}
+	return obj.(*v1.NotificationConfig), nil
+}
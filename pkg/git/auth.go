@@ -1,6 +1,8 @@
 package git
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
 	"io/ioutil"
 	"net/url"
@@ -9,22 +11,34 @@ import (
 )
 
 const (
-	BasicAuthUsernameKey = "username"
-	BasicAuthPasswordKey = "password"
-	SSHAuthPrivateKey    = "ssh-privatekey"
+	BasicAuthUsernameKey       = "username"
+	BasicAuthPasswordKey       = "password"
+	SSHAuthPrivateKey          = "ssh-privatekey"
+	TokenKey                   = "token"
+	GitHubAppIDKey             = "github-app-id"
+	GitHubAppInstallationIDKey = "github-app-installation-id"
+	GitHubAppPrivateKeyKey     = "github-app-private-key"
+	GitHubAppAPIURLKey         = "github-app-api-url"
+	CABundleKey                = "caBundle"
 )
 
 var ErrNoSecret = fmt.Errorf("failed to find one of the following keys in secret: %v", []string{
 	BasicAuthUsernameKey,
 	BasicAuthPasswordKey,
 	SSHAuthPrivateKey,
+	TokenKey,
+	GitHubAppPrivateKeyKey,
+	CABundleKey,
 })
 
 func noop() {}
 
 type Auth struct {
-	Basic Basic
-	SSH   SSH
+	Basic     Basic
+	SSH       SSH
+	Token     Token
+	GitHubApp GitHubApp
+	CABundle  []byte
 }
 
 type Basic struct {
@@ -36,23 +50,74 @@ type SSH struct {
 	Key []byte
 }
 
+// Token is sent as a bearer token in the Authorization header of every http request
+type Token struct {
+	Value string
+}
+
 func FromSecret(secret map[string][]byte) (Auth, error) {
 	auth := Auth{}
 	ok := auth.Basic.fromSecret(secret)
-	ok = ok || auth.SSH.fromSecret(secret)
+	ok = auth.SSH.fromSecret(secret) || ok
+	ok = auth.Token.fromSecret(secret) || ok
+
+	appOK, err := auth.GitHubApp.fromSecret(secret)
+	if err != nil {
+		return auth, err
+	}
+	ok = appOK || ok
+
+	if ca, caOK := secret[CABundleKey]; caOK {
+		auth.CABundle = ca
+		ok = true
+	}
+
 	if !ok {
 		return auth, ErrNoSecret
 	}
 	return auth, nil
 }
 
-func (a Auth) Populate(url string) (string, []string, func()) {
+// Populate returns the url and environment git should be run with for this auth along with a
+// func to clean up any temporary files. Credentials other than basic auth are passed through
+// the environment so they never show up in the process args or the url.
+func (a Auth) Populate(ctx context.Context, url string) (string, []string, func(), error) {
+	var closers []func()
+	close := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+
 	url = a.Basic.populate(url)
-	env, close := a.SSH.populate()
+	env, sshClose := a.SSH.populate()
+	closers = append(closers, sshClose)
 	if len(env) == 0 {
 		env = []string{"GIT_SSH_COMMAND=ssh -o UserKnownHostsFile=/dev/null -o StrictHostKeyChecking=no"}
 	}
-	return url, env, close
+
+	caEnv, caClose, err := populateCABundle(a.CABundle)
+	closers = append(closers, caClose)
+	if err != nil {
+		close()
+		return "", nil, noop, err
+	}
+	env = append(env, caEnv...)
+
+	header := a.Token.header()
+	if a.GitHubApp.isSet() {
+		token, err := a.GitHubApp.installationToken(ctx, a.CABundle)
+		if err != nil {
+			close()
+			return "", nil, noop, err
+		}
+		header = basicHeader(gitHubAppUsername, token)
+	}
+	if header != "" {
+		env = append(env, headerEnv(header)...)
+	}
+
+	return url, env, close, nil
 }
 
 func (b *Basic) fromSecret(secret map[string][]byte) bool {
@@ -116,3 +181,57 @@ func (s *SSH) populate() ([]string, func()) {
 		fmt.Sprintf("GIT_SSH_COMMAND=ssh -o UserKnownHostsFile=/dev/null -o StrictHostKeyChecking=no -i %s", f.Name()),
 	}, close
 }
+
+func (t *Token) fromSecret(secret map[string][]byte) bool {
+	token, ok := secret[TokenKey]
+	if ok {
+		t.Value = strings.TrimSpace(string(token))
+	}
+	return ok
+}
+
+func (t *Token) header() string {
+	if t.Value == "" {
+		return ""
+	}
+	return "Authorization: Bearer " + t.Value
+}
+
+func basicHeader(username, password string) string {
+	creds := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return "Authorization: Basic " + creds
+}
+
+// headerEnv sets http.extraHeader through the environment, requires git 2.31 or newer
+func headerEnv(header string) []string {
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=" + header,
+	}
+}
+
+func populateCABundle(ca []byte) ([]string, func(), error) {
+	if len(ca) == 0 {
+		return nil, noop, nil
+	}
+
+	f, err := ioutil.TempFile("", "ca-bundle")
+	if err != nil {
+		return nil, noop, err
+	}
+	close := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	if _, err := f.Write(ca); err != nil {
+		return nil, close, err
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, close, err
+	}
+
+	return []string{"GIT_SSL_CAINFO=" + f.Name()}, close, nil
+}
@@ -0,0 +1,204 @@
+package git
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRepo creates a bare repository with a single commit on master and returns
+// the directory holding it along with the commit sha, config is passed to git commit
+// as -c key=value pairs
+func newRepo(t *testing.T, config ...string) (string, string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	root, err := ioutil.TempDir("", "git-root")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	work := filepath.Join(root, "work")
+	run := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	require.NoError(t, os.MkdirAll(work, 0755))
+	run(work, "init", "-q")
+	run(work, "checkout", "-q", "-b", "master")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(work, "main.tf"), []byte(`output "a" { value = "a" }`), 0644))
+	run(work, "add", ".")
+	var commitArgs []string
+	for _, c := range config {
+		commitArgs = append(commitArgs, "-c", c)
+	}
+	run(work, append(commitArgs, "commit", "-q", "-m", "init")...)
+	commit := run(work, "rev-parse", "HEAD")
+	run(root, "clone", "-q", "--bare", work, "repo.git")
+
+	return root, commit
+}
+
+// gitServer serves the repositories under root over smart http, rejecting any
+// request whose Authorization header does not match
+func gitServer(t *testing.T, root, authorization string) http.Handler {
+	gitPath, err := exec.LookPath("git")
+	require.NoError(t, err)
+
+	backend := &cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if authorization != "" && req.Header.Get("Authorization") != authorization {
+			rw.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		backend.ServeHTTP(rw, req)
+	})
+}
+
+func TestFromSecret(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := FromSecret(map[string][]byte{})
+	assert.Equal(ErrNoSecret, err)
+
+	auth, err := FromSecret(map[string][]byte{TokenKey: []byte("abc\n")})
+	assert.NoError(err)
+	assert.Equal("abc", auth.Token.Value)
+
+	auth, err = FromSecret(map[string][]byte{CABundleKey: []byte("ca")})
+	assert.NoError(err)
+	assert.Equal([]byte("ca"), auth.CABundle)
+
+	_, err = FromSecret(map[string][]byte{GitHubAppPrivateKeyKey: []byte("key")})
+	assert.Error(err)
+
+	auth, err = FromSecret(map[string][]byte{
+		GitHubAppPrivateKeyKey:     []byte("key"),
+		GitHubAppIDKey:             []byte("12"),
+		GitHubAppInstallationIDKey: []byte("34"),
+	})
+	assert.NoError(err)
+	assert.Equal(int64(12), auth.GitHubApp.AppID)
+	assert.Equal(int64(34), auth.GitHubApp.InstallationID)
+	assert.Equal(defaultGitHubAPIURL, auth.GitHubApp.APIURL)
+}
+
+func TestGetCommitWithToken(t *testing.T) {
+	root, commit := newRepo(t)
+	server := httptest.NewServer(gitServer(t, root, "Bearer s3cr3t"))
+	defer server.Close()
+
+	url := server.URL + "/repo.git"
+	ctx := context.Background()
+
+	_, err := GetCommit(ctx, url, "master", "", &Auth{})
+	assert.Error(t, err)
+
+	auth, err := FromSecret(map[string][]byte{TokenKey: []byte("s3cr3t")})
+	require.NoError(t, err)
+
+	populated, env, close, err := auth.Populate(ctx, url)
+	require.NoError(t, err)
+	close()
+	assert.Equal(t, url, populated, "token must not be embedded in the url")
+	assert.Contains(t, env, "GIT_CONFIG_VALUE_0=Authorization: Bearer s3cr3t")
+
+	found, err := GetCommit(ctx, url, "master", "", &auth)
+	require.NoError(t, err)
+	assert.Equal(t, commit, found)
+}
+
+func TestGetCommitWithCABundle(t *testing.T) {
+	root, commit := newRepo(t)
+	server := httptest.NewTLSServer(gitServer(t, root, ""))
+	defer server.Close()
+
+	url := server.URL + "/repo.git"
+	ctx := context.Background()
+
+	_, err := GetCommit(ctx, url, "master", "", &Auth{})
+	assert.Error(t, err)
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	auth, err := FromSecret(map[string][]byte{CABundleKey: ca})
+	require.NoError(t, err)
+
+	found, err := GetCommit(ctx, url, "master", "", &auth)
+	require.NoError(t, err)
+	assert.Equal(t, commit, found)
+}
+
+func TestGetCommitWithGitHubApp(t *testing.T) {
+	root, commit := newRepo(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	api := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.URL.Path != "/app/installations/34/access_tokens" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		parts := strings.Split(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "), ".")
+		if len(parts) != 3 {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		hashed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig); err != nil {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		rw.WriteHeader(http.StatusCreated)
+		rw.Write([]byte(`{"token": "installation-token"}`))
+	}))
+	defer api.Close()
+
+	expected := "Basic " + base64.StdEncoding.EncodeToString([]byte("x-access-token:installation-token"))
+	server := httptest.NewServer(gitServer(t, root, expected))
+	defer server.Close()
+
+	auth, err := FromSecret(map[string][]byte{
+		GitHubAppPrivateKeyKey:     keyPEM,
+		GitHubAppIDKey:             []byte("12"),
+		GitHubAppInstallationIDKey: []byte("34"),
+		GitHubAppAPIURLKey:         []byte(api.URL + "/"),
+	})
+	require.NoError(t, err)
+
+	found, err := GetCommit(context.Background(), server.URL+"/repo.git", "master", "", &auth)
+	require.NoError(t, err)
+	assert.Equal(t, commit, found)
+}
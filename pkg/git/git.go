@@ -8,7 +8,10 @@ import (
 )
 
 func GetCommit(ctx context.Context, url, branch, tag string, auth *Auth) (string, error) {
-	url, env, close := auth.Populate(url)
+	url, env, close, err := auth.Populate(ctx, url)
+	if err != nil {
+		return "", err
+	}
 	defer close()
 
 	lines, err := git(ctx, env, "ls-remote", url, formatRef(branch, tag))
@@ -20,17 +23,25 @@ func GetCommit(ctx context.Context, url, branch, tag string, auth *Auth) (string
 }
 
 func CloneRepo(ctx context.Context, url string, commit string, auth *Auth) error {
-	url, env, close := auth.Populate(url)
+	return CloneRepoDir(ctx, url, commit, ".", auth)
+}
+
+// CloneRepoDir clones the repo into dir and checks out commit, which may also be a branch or tag
+func CloneRepoDir(ctx context.Context, url, commit, dir string, auth *Auth) error {
+	url, env, close, err := auth.Populate(ctx, url)
+	if err != nil {
+		return err
+	}
 	defer close()
 
-	lines, err := git(ctx, env, "clone", "-n", url, ".")
+	lines, err := git(ctx, env, "clone", "-n", url, dir)
 	if err != nil {
 		return err
 	}
 
 	logrus.Infof("Output from git clone %v", lines)
 
-	lines, err = git(ctx, env, "checkout", commit)
+	lines, err = git(ctx, env, "-C", dir, "checkout", commit)
 	if err != nil {
 		return err
 	}
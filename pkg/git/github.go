@@ -0,0 +1,194 @@
+package git
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+	gitHubAppUsername   = "x-access-token"
+)
+
+// GitHubApp mints short lived installation tokens from a GitHub App private key
+type GitHubApp struct {
+	AppID          int64
+	InstallationID int64
+	PrivateKey     []byte
+	APIURL         string
+}
+
+func (g *GitHubApp) fromSecret(secret map[string][]byte) (bool, error) {
+	key, ok := secret[GitHubAppPrivateKeyKey]
+	if !ok {
+		return false, nil
+	}
+	g.PrivateKey = key
+
+	appID, err := parseSecretInt(secret, GitHubAppIDKey)
+	if err != nil {
+		return false, err
+	}
+	g.AppID = appID
+
+	installationID, err := parseSecretInt(secret, GitHubAppInstallationIDKey)
+	if err != nil {
+		return false, err
+	}
+	g.InstallationID = installationID
+
+	g.APIURL = defaultGitHubAPIURL
+	if apiURL, ok := secret[GitHubAppAPIURLKey]; ok && len(apiURL) > 0 {
+		g.APIURL = strings.TrimSuffix(strings.TrimSpace(string(apiURL)), "/")
+	}
+
+	return true, nil
+}
+
+func (g *GitHubApp) isSet() bool {
+	return len(g.PrivateKey) > 0
+}
+
+// installationToken exchanges a JWT signed by the app private key for an installation token
+func (g *GitHubApp) installationToken(ctx context.Context, caBundle []byte) (string, error) {
+	jwt, err := g.signJWT(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	client, err := httpClient(caBundle)
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", g.APIURL, g.InstallationID)
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "requesting github app installation token")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("requesting github app installation token: unexpected status %s", resp.Status)
+	}
+
+	token := struct {
+		Token string `json:"token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", errors.Wrap(err, "decoding github app installation token")
+	}
+	if token.Token == "" {
+		return "", errors.New("github app installation token response did not contain a token")
+	}
+
+	return token.Token, nil
+}
+
+func (g *GitHubApp) signJWT(now time.Time) (string, error) {
+	key, err := parseRSAPrivateKey(g.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	// backdate issued at to allow for clock drift, github caps expiry at 10 minutes
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": g.AppID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hashed := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("github app private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing github app private key")
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("github app private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+func parseSecretInt(secret map[string][]byte, key string) (int64, error) {
+	value, ok := secret[key]
+	if !ok {
+		return 0, fmt.Errorf("%s is set but %s is missing", GitHubAppPrivateKeyKey, key)
+	}
+	i, err := strconv.ParseInt(strings.TrimSpace(string(value)), 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "parsing %s", key)
+	}
+	return i, nil
+}
+
+func httpClient(caBundle []byte) (*http.Client, error) {
+	if len(caBundle) == 0 {
+		return &http.Client{Timeout: 30 * time.Second}, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("no certificates found in %s", CABundleKey)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: transport,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+)
+
+const peeledSuffix = "^{}"
+
+// ListTags returns all tags in the remote repo mapped to the commit they point to
+func ListTags(ctx context.Context, url string, auth *Auth) (map[string]string, error) {
+	url, env, close, err := auth.Populate(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer close()
+
+	lines, err := git(ctx, env, "ls-remote", "--tags", url)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]string{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "refs/tags/") {
+			continue
+		}
+		name := strings.TrimPrefix(fields[1], "refs/tags/")
+		// annotated tags are listed twice, prefer the peeled ref pointing at the commit
+		if strings.HasSuffix(name, peeledSuffix) {
+			tags[strings.TrimSuffix(name, peeledSuffix)] = fields[0]
+		} else if _, ok := tags[name]; !ok {
+			tags[name] = fields[0]
+		}
+	}
+
+	return tags, nil
+}
+
+// LatestTag returns the highest semver tag matching constraint along with its commit
+func LatestTag(ctx context.Context, url, constraint string, auth *Auth) (string, string, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "invalid semver constraint %q", constraint)
+	}
+
+	tags, err := ListTags(ctx, url, auth)
+	if err != nil {
+		return "", "", err
+	}
+
+	tag, ok := highestMatch(tags, c)
+	if !ok {
+		return "", "", fmt.Errorf("no tag matches semver constraint %q", constraint)
+	}
+	return tag, tags[tag], nil
+}
+
+func highestMatch(tags map[string]string, c *semver.Constraints) (string, bool) {
+	var (
+		latest    *semver.Version
+		latestTag string
+	)
+	for tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+		// tags like v1.0.0 and 1.0.0 are the same version, pick the same one every time
+		if latest == nil || v.GreaterThan(latest) || (v.Equal(latest) && tag < latestTag) {
+			latest = v
+			latestTag = tag
+		}
+	}
+	return latestTag, latest != nil
+}
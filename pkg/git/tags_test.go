@@ -0,0 +1,39 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHighestMatch(t *testing.T) {
+	tags := map[string]string{
+		"v1.3.9":       "a",
+		"v1.4.0":       "b",
+		"v1.4.2":       "c",
+		"v1.5.0":       "d",
+		"v2.1.0":       "e",
+		"v3.0.0-rc.1":  "f",
+		"release-next": "g",
+	}
+
+	tests := map[string]string{
+		"~1.4":         "v1.4.2",
+		">=2.0.0 <3":   "v2.1.0",
+		"^1":           "v1.5.0",
+		"*":            "v2.1.0",
+		">=3.0.0-rc.0": "v3.0.0-rc.1",
+		">4":           "",
+	}
+
+	for constraint, expected := range tests {
+		c, err := semver.NewConstraint(constraint)
+		require.NoError(t, err)
+
+		tag, ok := highestMatch(tags, c)
+		assert.Equal(t, expected != "", ok, constraint)
+		assert.Equal(t, expected, tag, constraint)
+	}
+}
@@ -0,0 +1,140 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const pgpPublicKeyHeader = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+
+// Keys are the signing keys a commit is trusted to be signed with
+type Keys struct {
+	GPG [][]byte
+	SSH []string
+}
+
+// KeysFromSecret reads trusted keys from every entry in a secret. Entries holding an armored
+// PGP public key block are imported as GPG keys, every other non empty line is treated as an
+// SSH public key in authorized_keys format.
+func KeysFromSecret(secret map[string][]byte) (Keys, error) {
+	keys := Keys{}
+	for _, value := range secret {
+		if bytes.Contains(value, []byte(pgpPublicKeyHeader)) {
+			keys.GPG = append(keys.GPG, value)
+			continue
+		}
+
+		s := bufio.NewScanner(bytes.NewReader(value))
+		for s.Scan() {
+			line := strings.TrimSpace(s.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			keys.SSH = append(keys.SSH, line)
+		}
+		if err := s.Err(); err != nil {
+			return keys, err
+		}
+	}
+
+	if len(keys.GPG) == 0 && len(keys.SSH) == 0 {
+		return keys, errors.New("no GPG or SSH public keys found in verification secret")
+	}
+	return keys, nil
+}
+
+// VerifyCommit fetches a single commit from the repo and checks it is signed by one of the keys
+func VerifyCommit(ctx context.Context, url, commit string, auth *Auth, keys Keys) error {
+	if commit == "" {
+		return errors.New("no commit to verify")
+	}
+
+	dir, err := ioutil.TempDir("", "git-verify")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	url, env, close, err := auth.Populate(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer close()
+
+	if _, err := git(ctx, env, "-C", dir, "init", "-q"); err != nil {
+		return err
+	}
+
+	if _, err := git(ctx, env, "-C", dir, "fetch", "-q", "--depth=1", url, commit); err != nil {
+		return errors.Wrapf(err, "fetching commit %s", commit)
+	}
+
+	// annotated tags resolve to the tag object, verify the commit it points to
+	lines, err := git(ctx, env, "-C", dir, "rev-parse", commit+"^{commit}")
+	if err != nil {
+		return err
+	}
+	commit, err = firstField(lines, fmt.Sprintf("no commit for %s", commit))
+	if err != nil {
+		return err
+	}
+
+	verifyEnv, err := keys.populate(ctx, dir)
+	if err != nil {
+		return err
+	}
+
+	if _, err := git(ctx, verifyEnv, "-C", dir, "verify-commit", commit); err != nil {
+		return fmt.Errorf("commit %s is not signed by a trusted key: %v", commit, err)
+	}
+	return nil
+}
+
+// populate writes a gpg home and ssh allowed signers file under dir and returns the env needed
+// for git verify-commit to use them
+func (k Keys) populate(ctx context.Context, dir string) ([]string, error) {
+	gnupgHome := filepath.Join(dir, "gnupg")
+	if err := os.Mkdir(gnupgHome, 0700); err != nil {
+		return nil, err
+	}
+	env := []string{"GNUPGHOME=" + gnupgHome}
+
+	for _, key := range k.GPG {
+		cmd := exec.CommandContext(ctx, "gpg", "--batch", "--quiet", "--import")
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Stdin = bytes.NewReader(key)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return nil, errors.Wrapf(err, "importing gpg key: %s", out)
+		}
+	}
+
+	if len(k.SSH) > 0 {
+		var signers strings.Builder
+		for _, key := range k.SSH {
+			signers.WriteString("* ")
+			signers.WriteString(key)
+			signers.WriteString("\n")
+		}
+
+		signersFile := filepath.Join(dir, "allowed_signers")
+		if err := ioutil.WriteFile(signersFile, []byte(signers.String()), 0600); err != nil {
+			return nil, err
+		}
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=gpg.ssh.allowedSignersFile",
+			"GIT_CONFIG_VALUE_0="+signersFile,
+		)
+	}
+
+	return env, nil
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sshKey(t *testing.T, dir, name string) (string, []byte) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not installed")
+	}
+
+	path := filepath.Join(dir, name)
+	out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", path).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	pub, err := ioutil.ReadFile(path + ".pub")
+	require.NoError(t, err)
+	return path, pub
+}
+
+func TestVerifyCommit(t *testing.T) {
+	keyDir, err := ioutil.TempDir("", "keys")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyDir)
+	signingKey, trusted := sshKey(t, keyDir, "trusted")
+	_, untrusted := sshKey(t, keyDir, "untrusted")
+
+	signedRoot, signed := newRepo(t, "gpg.format=ssh", "user.signingkey="+signingKey, "commit.gpgsign=true")
+	signedServer := httptest.NewServer(gitServer(t, signedRoot, ""))
+	defer signedServer.Close()
+
+	unsignedRoot, unsigned := newRepo(t)
+	unsignedServer := httptest.NewServer(gitServer(t, unsignedRoot, ""))
+	defer unsignedServer.Close()
+
+	keys, err := KeysFromSecret(map[string][]byte{"trusted": trusted})
+	require.NoError(t, err)
+	otherKeys, err := KeysFromSecret(map[string][]byte{"untrusted": untrusted})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.NoError(t, VerifyCommit(ctx, signedServer.URL+"/repo.git", signed, &Auth{}, keys))
+	assert.Error(t, VerifyCommit(ctx, signedServer.URL+"/repo.git", signed, &Auth{}, otherKeys))
+	assert.Error(t, VerifyCommit(ctx, unsignedServer.URL+"/repo.git", unsigned, &Auth{}, keys))
+
+	_, err = KeysFromSecret(map[string][]byte{"empty": []byte("\n# comment\n")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+// Package notification renders notifications about executions and sends them to webhooks,
+// Slack-compatible webhooks and SMTP servers
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+)
+
+const (
+	EventPlanned          = "planned"
+	EventAwaitingApproval = "awaitingApproval"
+	EventApplied          = "applied"
+	EventFailed           = "failed"
+)
+
+// Events are the events notifications are sent for, in the order they happen
+var Events = []string{EventPlanned, EventAwaitingApproval, EventApplied, EventFailed}
+
+// DefaultTemplate is the message of configs and sinks that don't set a template
+const DefaultTemplate = `[{{ .Namespace }}/{{ .State }}] {{ .Title }}
+{{- with .PlanSummary }}
+{{ . }}
+{{- end }}
+{{- with .Cost }}
+Cost: {{ . }}
+{{- end }}
+{{- with .Error }}
+Error: {{ . }}
+{{- end }}
+{{- if eq .Event "awaitingApproval" }}
+Approve: {{ .ApproveCommand }}
+Deny: {{ .DenyCommand }}
+{{- end }}`
+
+// Notification is what messages are rendered from, and what webhook sinks post
+type Notification struct {
+	Event     string `json:"event"`
+	Namespace string `json:"namespace"`
+	State     string `json:"state"`
+	Execution string `json:"execution"`
+	// PlanSummary is e.g. "Plan: 1 to add, 0 to change, 0 to destroy."
+	PlanSummary string `json:"planSummary,omitempty"`
+	// Cost is the estimated monthly cost change, e.g. "+12.50 USD"
+	Cost string `json:"cost,omitempty"`
+	// Error is why the execution failed
+	Error string `json:"error,omitempty"`
+	// ApproveCommand and DenyCommand are tffy commands deciding on the plan
+	ApproveCommand string    `json:"approveCommand"`
+	DenyCommand    string    `json:"denyCommand"`
+	Time           time.Time `json:"time"`
+}
+
+// New returns the notification of the event of the execution
+func New(event string, execution *v1.Execution) Notification {
+	n := Notification{
+		Event:          event,
+		Namespace:      execution.Namespace,
+		State:          execution.Spec.ExecutionName,
+		Execution:      execution.Name,
+		PlanSummary:    execution.Status.PlanSummary,
+		ApproveCommand: fmt.Sprintf("tffy --namespace %s executions approve %s", execution.Namespace, execution.Name),
+		DenyCommand:    fmt.Sprintf("tffy --namespace %s executions deny %s", execution.Namespace, execution.Name),
+		Time:           time.Now().UTC(),
+	}
+	if cost := execution.Status.Cost; cost != nil && cost.DiffTotalMonthlyCost != "" {
+		n.Cost = strings.TrimSpace(fmt.Sprintf("%s %s/month", signed(cost.DiffTotalMonthlyCost), cost.Currency))
+	}
+	if event == EventFailed {
+		n.Error = v1.ExecutionRunConditionFailed.GetMessage(execution)
+	}
+	return n
+}
+
+// Title is a one line description of the event
+func (n Notification) Title() string {
+	switch n.Event {
+	case EventPlanned:
+		return fmt.Sprintf("Execution %s planned", n.Execution)
+	case EventAwaitingApproval:
+		return fmt.Sprintf("Execution %s is waiting for approval", n.Execution)
+	case EventApplied:
+		return fmt.Sprintf("Execution %s applied", n.Execution)
+	case EventFailed:
+		return fmt.Sprintf("Execution %s failed", n.Execution)
+	}
+	return fmt.Sprintf("Execution %s: %s", n.Execution, n.Event)
+}
+
+// Render executes the Go text/template text, or DefaultTemplate when empty, with n
+func Render(text string, n Notification) (string, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	t, err := template.New("notification").Parse(text)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing notification template")
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, n); err != nil {
+		return "", errors.Wrap(err, "rendering notification template")
+	}
+	return b.String(), nil
+}
+
+// signed prefixes positive amounts with a plus
+func signed(amount string) string {
+	if amount == "" || strings.HasPrefix(amount, "-") || strings.HasPrefix(amount, "+") {
+		return amount
+	}
+	return "+" + amount
+}
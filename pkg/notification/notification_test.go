@@ -0,0 +1,38 @@
+package notification
+
+import (
+	"testing"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRender(t *testing.T) {
+	execution := &v1.Execution{
+		ObjectMeta: metav1.ObjectMeta{Name: "vpc-x7k2p", Namespace: "infra"},
+		Spec:       v1.ExecutionSpec{ExecutionName: "vpc"},
+		Status: v1.ExecutionStatus{
+			PlanSummary: "Plan: 2 to add, 0 to change, 1 to destroy.",
+			Cost:        &v1.CostEstimate{Currency: "USD", DiffTotalMonthlyCost: "12.50"},
+		},
+	}
+
+	message, err := Render("", New(EventAwaitingApproval, execution))
+	require.NoError(t, err)
+	assert.Equal(t, `[infra/vpc] Execution vpc-x7k2p is waiting for approval
+Plan: 2 to add, 0 to change, 1 to destroy.
+Cost: +12.50 USD/month
+Approve: tffy --namespace infra executions approve vpc-x7k2p
+Deny: tffy --namespace infra executions deny vpc-x7k2p`, message)
+
+	v1.ExecutionRunConditionFailed.True(execution)
+	v1.ExecutionRunConditionFailed.Message(execution, "exit status 1")
+	message, err = Render(`{{ .Title }}: {{ .Error }}`, New(EventFailed, execution))
+	require.NoError(t, err)
+	assert.Equal(t, "Execution vpc-x7k2p failed: exit status 1", message)
+
+	_, err = Render(`{{ .Unknown }}`, New(EventApplied, execution))
+	assert.Error(t, err)
+}
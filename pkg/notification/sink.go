@@ -0,0 +1,180 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultSMTPPort is the submission port SMTP sinks use when they don't set one
+	DefaultSMTPPort = 587
+	// smtpTimeout bounds dialing and sending a mail when the context has no deadline
+	smtpTimeout = 30 * time.Second
+)
+
+// Sink sends the rendered message of a notification
+type Sink interface {
+	Send(ctx context.Context, n Notification, message string) error
+}
+
+// Webhook posts the notification with its message as JSON
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+func (w *Webhook) Send(ctx context.Context, n Notification, message string) error {
+	return post(ctx, w.Client, w.URL, struct {
+		Notification
+		Message string `json:"message"`
+	}{n, message})
+}
+
+// Slack posts the message to a Slack-compatible incoming webhook, which Mattermost, Rocket.Chat
+// and others accept too
+type Slack struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *Slack) Send(ctx context.Context, n Notification, message string) error {
+	return post(ctx, s.Client, s.URL, map[string]string{"text": message})
+}
+
+// SMTP mails the message with the notification's title as subject. The connection is upgraded
+// with STARTTLS when the server supports it, which PLAIN authentication requires.
+type SMTP struct {
+	Host     string
+	Port     int
+	From     string
+	To       []string
+	Username string
+	Password string
+}
+
+func (s *SMTP) Send(ctx context.Context, n Notification, message string) error {
+	port := s.Port
+	if port == 0 {
+		port = DefaultSMTPPort
+	}
+	for _, addr := range append([]string{s.From}, s.To...) {
+		if strings.ContainsAny(addr, "\r\n") {
+			return fmt.Errorf("invalid mail address %q", addr)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", n.Title())
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(message, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(port))
+	return errors.Wrapf(s.send(ctx, addr, []byte(b.String())), "sending mail to %s", addr)
+}
+
+// send is smtp.SendMail on a connection that is closed once ctx is done, net/smtp takes no
+// context and would otherwise wait on an unresponsive server until the OS gives up
+func (s *SMTP) send(ctx context.Context, addr string, msg []byte) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("server doesn't support AUTH")
+		}
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+	for _, to := range s.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func post(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("posting notification: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSinks(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		got = map[string]interface{}{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&got))
+		if req.URL.Path == "/fail" {
+			http.Error(rw, "invalid token", http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	n := Notification{Event: EventApplied, Namespace: "infra", State: "vpc", Execution: "vpc-x7k2p"}
+
+	require.NoError(t, (&Webhook{URL: server.URL}).Send(context.Background(), n, "applied"))
+	assert.Equal(t, "applied", got["event"])
+	assert.Equal(t, "vpc-x7k2p", got["execution"])
+	assert.Equal(t, "applied", got["message"])
+
+	require.NoError(t, (&Slack{URL: server.URL}).Send(context.Background(), n, "vpc applied"))
+	assert.Equal(t, map[string]interface{}{"text": "vpc applied"}, got)
+
+	err := (&Slack{URL: server.URL + "/fail"}).Send(context.Background(), n, "vpc applied")
+	assert.EqualError(t, err, "posting notification: 403 Forbidden: invalid token")
+}
+
+func TestSMTPTimeout(t *testing.T) {
+	// a server that accepts connections but never greets
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+	sink := &SMTP{Host: "127.0.0.1", Port: addr.Port, From: "tf@example.com", To: []string{"ops@example.com"}}
+	n := Notification{Event: EventApplied, Namespace: "infra", State: "vpc", Execution: "vpc-x7k2p"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = sink.Send(ctx, n, "applied")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "sending mail to 127.0.0.1:"+strconv.Itoa(addr.Port))
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Second))
+
+	sink.To = []string{"ops@example.com\r\nBcc: all@example.com"}
+	assert.Error(t, sink.Send(context.Background(), n, "applied"))
+}
@@ -0,0 +1,74 @@
+package oci
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+// Auth is the basic auth used to log in to a registry
+type Auth struct {
+	Username string
+	Password string
+}
+
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths"`
+}
+
+type dockerAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// FromSecret finds the credentials for host in a kubernetes.io/dockerconfigjson or
+// kubernetes.io/dockercfg pull secret
+func FromSecret(secret map[string][]byte, host string) (Auth, error) {
+	auths := map[string]dockerAuth{}
+	if data, ok := secret[coreV1.DockerConfigJsonKey]; ok {
+		config := dockerConfig{}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return Auth{}, errors.Wrap(err, "parsing docker config")
+		}
+		auths = config.Auths
+	} else if data, ok := secret[coreV1.DockerConfigKey]; ok {
+		if err := json.Unmarshal(data, &auths); err != nil {
+			return Auth{}, errors.Wrap(err, "parsing docker config")
+		}
+	} else {
+		return Auth{}, errors.Errorf("pull secret has no %s or %s key", coreV1.DockerConfigJsonKey, coreV1.DockerConfigKey)
+	}
+
+	for server, auth := range auths {
+		if normalizeHost(server) != normalizeHost(host) {
+			continue
+		}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return Auth{}, errors.Wrapf(err, "decoding auth for %s", server)
+			}
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) == 2 {
+				return Auth{Username: parts[0], Password: parts[1]}, nil
+			}
+		}
+		return Auth{Username: auth.Username, Password: auth.Password}, nil
+	}
+
+	return Auth{}, errors.Errorf("pull secret has no credentials for %s", host)
+}
+
+func normalizeHost(server string) string {
+	server = strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	server = strings.SplitN(server, "/", 2)[0]
+	switch server {
+	case "index.docker.io", dockerHubAPIHost:
+		return dockerHub
+	}
+	return server
+}
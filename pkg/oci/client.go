@@ -0,0 +1,314 @@
+// Package oci resolves and downloads module artifacts from OCI registries
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	MediaTypeManifest       = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+
+	// AnnotationTitle is the file name ORAS gives to each pushed file
+	AnnotationTitle = "org.opencontainers.image.title"
+	// AnnotationUnpack is set by ORAS when a pushed directory was packed into a tar+gzip layer
+	AnnotationUnpack = "io.deis.oras.content.unpack"
+
+	digestHeader    = "Docker-Content-Digest"
+	sha256Algorithm = "sha256:"
+	maxManifestSize = 4 << 20
+)
+
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type Manifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    Descriptor   `json:"config"`
+	Layers    []Descriptor `json:"layers"`
+}
+
+type Client struct {
+	HTTPClient *http.Client
+	Auth       Auth
+	// PlainHTTP talks to the registry over http instead of https
+	PlainHTTP bool
+
+	token string
+}
+
+func NewClient(auth Auth, plainHTTP bool) *Client {
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 5 * time.Minute},
+		Auth:       auth,
+		PlainHTTP:  plainHTTP,
+	}
+}
+
+// Resolve returns the digest of the manifest tagged tag
+func (c *Client) Resolve(ctx context.Context, ref Reference, tag string) (string, error) {
+	resp, err := c.get(ctx, ref, http.MethodHead, "manifests/"+tag)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if digest := resp.Header.Get(digestHeader); strings.HasPrefix(digest, sha256Algorithm) {
+		return digest, nil
+	}
+
+	// the digest header is optional, fall back to hashing the manifest
+	resp, err = c.get(ctx, ref, http.MethodGet, "manifests/"+tag)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return sha256Algorithm + hex.EncodeToString(sum[:]), nil
+}
+
+// Manifest fetches and verifies the manifest with the given digest
+func (c *Client) Manifest(ctx context.Context, ref Reference, digest string) (*Manifest, error) {
+	resp, err := c.get(ctx, ref, http.MethodGet, "manifests/"+digest)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(digest, body); err != nil {
+		return nil, errors.Wrapf(err, "manifest %s", digest)
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(body, manifest); err != nil {
+		return nil, errors.Wrapf(err, "parsing manifest %s", digest)
+	}
+	if len(manifest.Layers) == 0 {
+		return nil, fmt.Errorf("manifest %s of %s has no layers, indexes are not supported", digest, ref)
+	}
+	return manifest, nil
+}
+
+// Blob writes the blob for desc to w, returning an error if its content does not match the digest
+func (c *Client) Blob(ctx context.Context, ref Reference, desc Descriptor, w io.Writer) error {
+	digest, err := newDigester(desc.Digest)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.get(ctx, ref, http.MethodGet, "blobs/"+desc.Digest)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(io.MultiWriter(w, digest), resp.Body); err != nil {
+		return errors.Wrapf(err, "downloading blob %s", desc.Digest)
+	}
+	return digest.verify()
+}
+
+func (c *Client) get(ctx context.Context, ref Reference, method, path string) (*http.Response, error) {
+	u := url.URL{
+		Scheme: "https",
+		Host:   ref.apiHost(),
+		Path:   fmt.Sprintf("/v2/%s/%s", ref.Repository, path),
+	}
+	if c.PlainHTTP {
+		u.Scheme = "http"
+	}
+
+	resp, err := c.do(ctx, method, u.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && c.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := c.login(ctx, challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = c.do(ctx, method, u.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: unexpected status %s", method, u.String(), resp.Status)
+	}
+	return resp, nil
+}
+
+func (c *Client) do(ctx context.Context, method, u string) (*http.Response, error) {
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", MediaTypeManifest+", "+MediaTypeDockerManifest)
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+	return c.HTTPClient.Do(req.WithContext(ctx))
+}
+
+// login answers a WWW-Authenticate challenge, exchanging the basic auth for a bearer
+// token when the registry asks for one
+func (c *Client) login(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if c.Auth.Username == "" && c.Auth.Password == "" {
+			return errors.New("registry requires credentials, set a pull secret")
+		}
+		c.token = "Basic " + base64.StdEncoding.EncodeToString([]byte(c.Auth.Username+":"+c.Auth.Password))
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("unsupported registry auth challenge %q", challenge)
+	}
+
+	u, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return fmt.Errorf("invalid registry auth realm %q", params["realm"])
+	}
+	query := u.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.Auth.Username != "" || c.Auth.Password != "" {
+		req.SetBasicAuth(c.Auth.Username, c.Auth.Password)
+	}
+
+	resp, err := c.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "requesting registry token")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("requesting registry token: unexpected status %s", resp.Status)
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return errors.Wrap(err, "decoding registry token")
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return errors.New("registry token response did not contain a token")
+	}
+
+	c.token = "Bearer " + token.Token
+	return nil
+}
+
+// parseChallenge parses a header like: Bearer realm="https://auth",service="registry",scope="repository:a:pull"
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	scheme := strings.ToLower(parts[0])
+	if len(parts) == 1 {
+		return scheme, params
+	}
+
+	rest := parts[1]
+	for rest != "" {
+		eq := strings.Index(rest, "=")
+		if eq == -1 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end == -1 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if comma := strings.Index(rest, ","); comma > -1 {
+			value, rest = rest[:comma], rest[comma:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[key] = value
+		rest = strings.TrimLeft(rest, ", ")
+	}
+	return scheme, params
+}
+
+type digester struct {
+	hash.Hash
+	expected string
+}
+
+func newDigester(digest string) (*digester, error) {
+	if !strings.HasPrefix(digest, sha256Algorithm) {
+		return nil, fmt.Errorf("unsupported digest %s, only sha256 is supported", digest)
+	}
+	return &digester{Hash: sha256.New(), expected: digest}, nil
+}
+
+func (d *digester) verify() error {
+	actual := sha256Algorithm + hex.EncodeToString(d.Sum(nil))
+	if actual != d.expected {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", d.expected, actual)
+	}
+	return nil
+}
+
+func verify(digest string, content []byte) error {
+	d, err := newDigester(digest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(d, bytes.NewReader(content)); err != nil {
+		return err
+	}
+	return d.verify()
+}
@@ -0,0 +1,51 @@
+package oci
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	dockerHub        = "docker.io"
+	dockerHubAPIHost = "registry-1.docker.io"
+)
+
+// Reference is a repository in an OCI registry
+type Reference struct {
+	Host       string
+	Repository string
+}
+
+// ParseReference splits a reference like ghcr.io/org/modules/vpc into the registry host and
+// repository, references without a registry host are on docker hub
+func ParseReference(ref string) (Reference, error) {
+	ref = strings.TrimPrefix(strings.TrimPrefix(ref, "oci://"), "/")
+	if ref == "" {
+		return Reference{}, fmt.Errorf("empty oci reference")
+	}
+	if strings.ContainsAny(ref, "@") || strings.Contains(ref[strings.LastIndex(ref, "/")+1:], ":") {
+		return Reference{}, fmt.Errorf("oci reference %s must not include a tag or digest, use the tag and digest fields", ref)
+	}
+
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) == 1 || !(strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		repo := ref
+		if !strings.Contains(repo, "/") {
+			repo = "library/" + repo
+		}
+		return Reference{Host: dockerHub, Repository: repo}, nil
+	}
+
+	return Reference{Host: parts[0], Repository: parts[1]}, nil
+}
+
+func (r Reference) String() string {
+	return r.Host + "/" + r.Repository
+}
+
+func (r Reference) apiHost() string {
+	if r.Host == dockerHub {
+		return dockerHubAPIHost
+	}
+	return r.Host
+}
@@ -0,0 +1,28 @@
+package oci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := map[string]Reference{
+		"ghcr.io/org/modules/vpc":        {Host: "ghcr.io", Repository: "org/modules/vpc"},
+		"oci://ghcr.io/org/vpc":          {Host: "ghcr.io", Repository: "org/vpc"},
+		"localhost:5000/vpc":             {Host: "localhost:5000", Repository: "vpc"},
+		"org/vpc":                        {Host: "docker.io", Repository: "org/vpc"},
+		"vpc":                            {Host: "docker.io", Repository: "library/vpc"},
+		"registry.example.com:443/a/b/c": {Host: "registry.example.com:443", Repository: "a/b/c"},
+	}
+	for input, expected := range tests {
+		ref, err := ParseReference(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, ref, input)
+	}
+
+	for _, input := range []string{"", "ghcr.io/org/vpc:v1", "ghcr.io/org/vpc@sha256:abc"} {
+		_, err := ParseReference(input)
+		assert.Error(t, err, input)
+	}
+}
@@ -0,0 +1,255 @@
+// Package registry resolves modules through the terraform module registry protocol
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultHost = "registry.terraform.io"
+	// TokenKey is the key in a secret holding the registry API token
+	TokenKey = "token"
+
+	discoveryPath = "/.well-known/terraform.json"
+	modulesV1     = "modules.v1"
+)
+
+// Module identifies a module in a registry
+type Module struct {
+	Host      string
+	Namespace string
+	Name      string
+	Provider  string
+}
+
+// Resolved is the exact version selected for a constraint and the address to download it from
+type Resolved struct {
+	Version string
+	Source  string
+}
+
+type Client struct {
+	HTTPClient *http.Client
+	Token      string
+	// Scheme defaults to https, only meant to be changed for testing
+	Scheme string
+}
+
+func NewClient(token string) *Client {
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		Token:      token,
+		Scheme:     "https",
+	}
+}
+
+func (m Module) String() string {
+	return path.Join(m.host(), m.Namespace, m.Name, m.Provider)
+}
+
+func (m Module) host() string {
+	if m.Host == "" {
+		return DefaultHost
+	}
+	return m.Host
+}
+
+// Resolve finds the highest version of the module matching constraint and where to download it.
+// The constraint uses terraform syntax, e.g. "~> 1.2" or ">= 1.0, < 2.0", empty matches any version.
+func (c *Client) Resolve(ctx context.Context, m Module, constraint string) (*Resolved, error) {
+	if m.Namespace == "" || m.Name == "" || m.Provider == "" {
+		return nil, fmt.Errorf("registry module %s needs a namespace, name and provider", m)
+	}
+
+	base, err := c.discover(ctx, m.host())
+	if err != nil {
+		return nil, err
+	}
+	moduleURL := base.ResolveReference(&url.URL{Path: path.Join(m.Namespace, m.Name, m.Provider) + "/"})
+
+	versions, err := c.versions(ctx, moduleURL)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := latest(versions, constraint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "module %s", m)
+	}
+
+	source, err := c.download(ctx, moduleURL.ResolveReference(&url.URL{Path: v + "/download"}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resolved{
+		Version: v,
+		Source:  source,
+	}, nil
+}
+
+// discover returns the modules api base url advertised by the host
+func (c *Client) discover(ctx context.Context, host string) (*url.URL, error) {
+	discoveryURL := &url.URL{Scheme: c.scheme(), Host: host, Path: discoveryPath}
+
+	services := map[string]interface{}{}
+	if _, err := c.getJSON(ctx, discoveryURL, &services); err != nil {
+		return nil, errors.Wrapf(err, "discovering registry services on %s", host)
+	}
+
+	modules, ok := services[modulesV1].(string)
+	if !ok || modules == "" {
+		return nil, fmt.Errorf("registry %s does not support %s", host, modulesV1)
+	}
+	if !strings.HasSuffix(modules, "/") {
+		modules += "/"
+	}
+
+	ref, err := url.Parse(modules)
+	if err != nil {
+		return nil, err
+	}
+	return discoveryURL.ResolveReference(ref), nil
+}
+
+func (c *Client) versions(ctx context.Context, moduleURL *url.URL) ([]string, error) {
+	response := struct {
+		Modules []struct {
+			Versions []struct {
+				Version string `json:"version"`
+			} `json:"versions"`
+		} `json:"modules"`
+	}{}
+
+	if _, err := c.getJSON(ctx, moduleURL.ResolveReference(&url.URL{Path: "versions"}), &response); err != nil {
+		return nil, errors.Wrap(err, "listing module versions")
+	}
+
+	var versions []string
+	for _, module := range response.Modules {
+		for _, v := range module.Versions {
+			versions = append(versions, v.Version)
+		}
+	}
+	return versions, nil
+}
+
+// download returns the source address for a module version, registries either answer with a
+// X-Terraform-Get header or a json body holding the location
+func (c *Client) download(ctx context.Context, downloadURL *url.URL) (string, error) {
+	response := struct {
+		Location string `json:"location"`
+	}{}
+
+	resp, err := c.getJSON(ctx, downloadURL, &response)
+	if err != nil {
+		return "", errors.Wrap(err, "fetching module download location")
+	}
+
+	location := resp.Header.Get("X-Terraform-Get")
+	if location == "" {
+		location = response.Location
+	}
+	if location == "" {
+		return "", fmt.Errorf("registry did not return a download location for %s", downloadURL)
+	}
+
+	if !isRelative(location) {
+		return location, nil
+	}
+
+	// relative locations are relative to the download url
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return downloadURL.ResolveReference(u).String(), nil
+}
+
+func (c *Client) getJSON(ctx context.Context, u *url.URL, into interface{}) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, json.NewDecoder(resp.Body).Decode(into)
+	case http.StatusNoContent:
+		return resp, nil
+	default:
+		return nil, fmt.Errorf("GET %s: unexpected status %s", u, resp.Status)
+	}
+}
+
+func isRelative(location string) bool {
+	return strings.HasPrefix(location, "/") ||
+		strings.HasPrefix(location, "./") ||
+		strings.HasPrefix(location, "../")
+}
+
+func (c *Client) scheme() string {
+	if c.Scheme == "" {
+		return "https"
+	}
+	return c.Scheme
+}
+
+func latest(versions []string, constraint string) (string, error) {
+	var constraints version.Constraints
+	if constraint != "" {
+		var err error
+		constraints, err = version.NewConstraint(constraint)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid version constraint %q", constraint)
+		}
+	}
+
+	var (
+		latest    *version.Version
+		latestRaw string
+	)
+	for _, raw := range versions {
+		v, err := version.NewVersion(raw)
+		if err != nil {
+			continue
+		}
+		// like terraform, prereleases are only selected when asked for explicitly
+		if v.Prerelease() != "" && constraint != raw {
+			continue
+		}
+		if constraints != nil && !constraints.Check(v) {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+			latestRaw = raw
+		}
+	}
+
+	if latest == nil {
+		return "", fmt.Errorf("no version matches %q", constraint)
+	}
+	return latestRaw, nil
+}
@@ -0,0 +1,68 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRegistry serves the discovery, versions and download endpoints of the module registry protocol
+func fakeRegistry(token string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(`{"modules.v1": "/api/modules/v1/"}`))
+	})
+	mux.HandleFunc("/api/modules/v1/", func(rw http.ResponseWriter, req *http.Request) {
+		if token != "" && req.Header.Get("Authorization") != "Bearer "+token {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch req.URL.Path {
+		case "/api/modules/v1/org/vpc/aws/versions":
+			rw.Write([]byte(`{"modules":[{"versions":[{"version":"1.1.0"},{"version":"1.2.3"},{"version":"1.3.0-beta1"},{"version":"2.0.0"}]}]}`))
+		case "/api/modules/v1/org/vpc/aws/1.2.3/download":
+			rw.Header().Set("X-Terraform-Get", "git::https://example.com/org/vpc.git?ref=v1.2.3")
+			rw.WriteHeader(http.StatusNoContent)
+		case "/api/modules/v1/org/vpc/aws/2.0.0/download":
+			rw.Write([]byte(`{"location": "/archives/vpc-2.0.0.tar.gz"}`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestResolve(t *testing.T) {
+	server := fakeRegistry("t0ken")
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	client := NewClient("t0ken")
+	client.Scheme = "http"
+	module := Module{Host: u.Host, Namespace: "org", Name: "vpc", Provider: "aws"}
+	ctx := context.Background()
+
+	resolved, err := client.Resolve(ctx, module, "~> 1.1")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", resolved.Version)
+	assert.Equal(t, "git::https://example.com/org/vpc.git?ref=v1.2.3", resolved.Source)
+
+	resolved, err = client.Resolve(ctx, module, "")
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", resolved.Version)
+	assert.Equal(t, server.URL+"/archives/vpc-2.0.0.tar.gz", resolved.Source)
+
+	_, err = client.Resolve(ctx, module, ">= 3.0")
+	assert.Error(t, err)
+
+	client.Token = ""
+	_, err = client.Resolve(ctx, module, "")
+	assert.Error(t, err)
+}
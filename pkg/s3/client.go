@@ -0,0 +1,106 @@
+// Package s3 stores objects in S3 compatible object stores
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// AccessKeyIDKey and SecretAccessKeyKey are the keys of a secret holding the credentials
+	AccessKeyIDKey     = "accessKeyID"
+	SecretAccessKeyKey = "secretAccessKey"
+
+	defaultRegion = "us-east-1"
+	maxObjectSize = 64 << 20
+)
+
+// Client reads and writes objects of a bucket using path style requests, which every S3
+// compatible store supports
+type Client struct {
+	HTTPClient *http.Client
+	// Endpoint is the url of the store, e.g. https://s3.us-west-2.amazonaws.com
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+func NewClient(endpoint, region, bucket, accessKeyID, secretAccessKey string) *Client {
+	if region == "" {
+		region = defaultRegion
+	}
+	return &Client{
+		HTTPClient:      &http.Client{Timeout: 5 * time.Minute},
+		Endpoint:        endpoint,
+		Region:          region,
+		Bucket:          bucket,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+	}
+}
+
+// Put writes data to the object key
+func (c *Client) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := c.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get reads the object key
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxObjectSize))
+}
+
+// Delete removes the object key, deleting a missing object is not an error
+func (c *Client) Delete(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint %q: %v", c.Endpoint, err)
+	}
+	u.Path = path.Join("/", u.Path, c.Bucket, key)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	sign(req, body, c.Region, c.AccessKeyID, c.SecretAccessKey, time.Now())
+
+	resp, err := c.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: unexpected status %s: %s", method, u.String(), resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
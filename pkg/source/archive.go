@@ -0,0 +1,222 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const sha256Prefix = "sha256:"
+
+var (
+	// archiveClient bounds archive downloads, so a stalled server doesn't block the module handler
+	archiveClient = &http.Client{Timeout: 5 * time.Minute}
+	// maxArchiveSize and maxExtractedSize cap the bytes downloaded and written for an archive
+	maxArchiveSize   int64 = 256 << 20
+	maxExtractedSize int64 = 1 << 30
+)
+
+// getArchive downloads an archive, verifies its checksum and extracts it into dest. The
+// archive type comes from an "archive" query param or the url's extension.
+func getArchive(ctx context.Context, src, checksum, dest string) error {
+	u, err := url.Parse(src)
+	if err != nil {
+		return err
+	}
+
+	query := u.Query()
+	if checksum == "" {
+		checksum = query.Get("checksum")
+	}
+	archive := query.Get("archive")
+	query.Del("checksum")
+	query.Del("archive")
+	u.RawQuery = query.Encode()
+
+	if archive == "" {
+		archive = archiveType(u.Path)
+	}
+
+	f, err := ioutil.TempFile("", "module-archive")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := download(ctx, u.String(), checksum, f); err != nil {
+		return err
+	}
+
+	switch archive {
+	case "zip":
+		return extractZip(f.Name(), dest)
+	case "tar.gz", "tgz":
+		return extractTar(f.Name(), dest, true)
+	case "tar":
+		return extractTar(f.Name(), dest, false)
+	default:
+		return fmt.Errorf("unsupported archive type for %s", u.Redacted())
+	}
+}
+
+func archiveType(path string) string {
+	for _, ext := range []string{"tar.gz", "tgz", "tar", "zip"} {
+		if strings.HasSuffix(path, "."+ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+func download(ctx context.Context, src, checksum string, w io.Writer) error {
+	req, err := http.NewRequest(http.MethodGet, src, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := archiveClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading module archive: unexpected status %s", resp.Status)
+	}
+
+	digest := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, digest), io.LimitReader(resp.Body, maxArchiveSize+1))
+	if err != nil {
+		return errors.Wrap(err, "downloading module archive")
+	}
+	if n > maxArchiveSize {
+		return fmt.Errorf("module archive is larger than %d bytes", maxArchiveSize)
+	}
+
+	if checksum == "" {
+		return nil
+	}
+	if !strings.HasPrefix(checksum, sha256Prefix) {
+		return fmt.Errorf("unsupported checksum %q, only %s is supported", checksum, sha256Prefix)
+	}
+	actual := hex.EncodeToString(digest.Sum(nil))
+	if expected := strings.ToLower(strings.TrimPrefix(checksum, sha256Prefix)); actual != expected {
+		return fmt.Errorf("module archive checksum mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+func extractTar(path, dest string, gzipped bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	remaining := maxExtractedSize
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		target, err := securePath(dest, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			n, err := writeFile(target, io.LimitReader(tr, remaining+1), os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			if remaining -= n; remaining < 0 {
+				return fmt.Errorf("module archive extracts to more than %d bytes", maxExtractedSize)
+			}
+		}
+	}
+}
+
+func extractZip(path, dest string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	remaining := maxExtractedSize
+	for _, file := range zr.File {
+		target, err := securePath(dest, file.Name)
+		if err != nil {
+			return err
+		}
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		n, err := writeFile(target, io.LimitReader(rc, remaining+1), file.Mode().Perm())
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		if remaining -= n; remaining < 0 {
+			return fmt.Errorf("module archive extracts to more than %d bytes", maxExtractedSize)
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode|0600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return io.Copy(f, r)
+}
@@ -0,0 +1,93 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/oci"
+)
+
+// getOCI pulls the layers of an OCI artifact into dest. Tar layers, or layers ORAS marked
+// for unpacking, are extracted and every other layer is written to the file named by its
+// title annotation.
+func getOCI(ctx context.Context, location *v1.OCILocation, auth oci.Auth, dest string) error {
+	if location.Digest == "" {
+		return fmt.Errorf("oci module %s has not been resolved", location.Reference)
+	}
+
+	ref, err := oci.ParseReference(location.Reference)
+	if err != nil {
+		return err
+	}
+
+	client := oci.NewClient(auth, location.PlainHTTP)
+	manifest, err := client.Manifest(ctx, ref, location.Digest)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+
+	for _, layer := range manifest.Layers {
+		if err := getLayer(ctx, client, ref, layer, dest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getLayer(ctx context.Context, client *oci.Client, ref oci.Reference, layer oci.Descriptor, dest string) error {
+	title := layer.Annotations[oci.AnnotationTitle]
+	gzipped, archive := layerArchive(layer)
+
+	if !archive {
+		if title == "" {
+			return fmt.Errorf("layer %s has no %s annotation", layer.Digest, oci.AnnotationTitle)
+		}
+		target, err := securePath(dest, title)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return client.Blob(ctx, ref, layer, f)
+	}
+
+	f, err := ioutil.TempFile("", "module-layer")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := client.Blob(ctx, ref, layer, f); err != nil {
+		return err
+	}
+	return extractTar(f.Name(), dest, gzipped)
+}
+
+func layerArchive(layer oci.Descriptor) (gzipped bool, archive bool) {
+	if layer.Annotations[oci.AnnotationUnpack] == "true" {
+		return true, true
+	}
+	switch {
+	case strings.HasSuffix(layer.MediaType, "tar+gzip"), strings.HasSuffix(layer.MediaType, ".tar.gzip"):
+		return true, true
+	case strings.HasSuffix(layer.MediaType, "tar"), strings.HasSuffix(layer.MediaType, ".tar"):
+		return false, true
+	}
+	return false, false
+}
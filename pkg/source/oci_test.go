@@ -0,0 +1,133 @@
+package source
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/oci"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ociRegistry serves a single artifact tagged v1 behind bearer token auth
+func ociRegistry(t *testing.T, blobs map[string][]byte, layers []oci.Descriptor) (*httptest.Server, string) {
+	manifest, err := json.Marshal(oci.Manifest{
+		MediaType: oci.MediaTypeManifest,
+		Config:    oci.Descriptor{MediaType: "application/vnd.oci.image.config.v1+json", Digest: sha256Digest([]byte("{}")), Size: 2},
+		Layers:    layers,
+	})
+	require.NoError(t, err)
+	digest := sha256Digest(manifest)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			if user, pass, _ := req.BasicAuth(); user != "bot" || pass != "s3cr3t" {
+				rw.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			rw.Write([]byte(`{"token":"t0ken"}`))
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer t0ken" {
+			rw.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test",scope="repository:modules/vpc:pull"`)
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch path := strings.TrimPrefix(req.URL.Path, "/v2/modules/vpc/"); path {
+		case "manifests/v1", "manifests/" + digest:
+			rw.Header().Set("Content-Type", oci.MediaTypeManifest)
+			rw.Header().Set("Docker-Content-Digest", digest)
+			rw.Write(manifest)
+		default:
+			blob, ok := blobs[strings.TrimPrefix(path, "blobs/")]
+			if !ok {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			rw.Write(blob)
+		}
+	}))
+	return server, digest
+}
+
+func TestGetOCI(t *testing.T) {
+	archive := tarball(t, map[string]string{
+		"main.tf":          "# main",
+		"modules/a/sub.tf": "# sub",
+	})
+	readme := []byte("# vpc")
+	blobs := map[string][]byte{
+		sha256Digest(archive): archive,
+		sha256Digest(readme):  readme,
+	}
+	layers := []oci.Descriptor{
+		{
+			MediaType:   "application/vnd.oci.image.layer.v1.tar+gzip",
+			Digest:      sha256Digest(archive),
+			Size:        int64(len(archive)),
+			Annotations: map[string]string{oci.AnnotationTitle: "module"},
+		},
+		{
+			MediaType:   "text/markdown",
+			Digest:      sha256Digest(readme),
+			Size:        int64(len(readme)),
+			Annotations: map[string]string{oci.AnnotationTitle: "README.md"},
+		},
+	}
+
+	server, digest := ociRegistry(t, blobs, layers)
+	defer server.Close()
+
+	ref, err := oci.ParseReference(strings.TrimPrefix(server.URL, "http://") + "/modules/vpc")
+	require.NoError(t, err)
+
+	auth := oci.Auth{Username: "bot", Password: "s3cr3t"}
+	resolved, err := oci.NewClient(auth, true).Resolve(context.Background(), ref, "v1")
+	require.NoError(t, err)
+	assert.Equal(t, digest, resolved)
+
+	_, err = oci.NewClient(oci.Auth{}, true).Resolve(context.Background(), ref, "v1")
+	assert.Error(t, err)
+
+	dest, err := ioutil.TempDir("", "oci-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest)
+
+	location := &v1.OCILocation{
+		Reference: ref.String(),
+		Tag:       "v1",
+		Digest:    resolved,
+		PlainHTTP: true,
+	}
+	require.NoError(t, getOCI(context.Background(), location, auth, dest))
+
+	for name, expected := range map[string]string{
+		"main.tf":          "# main",
+		"modules/a/sub.tf": "# sub",
+		"README.md":        "# vpc",
+	} {
+		content, err := ioutil.ReadFile(filepath.Join(dest, name))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(content))
+	}
+
+	blobs[sha256Digest(readme)] = []byte("# tampered")
+	assert.Error(t, getOCI(context.Background(), location, auth, dest))
+}
@@ -0,0 +1,194 @@
+// Package source fetches module content into the directory terraform is run in
+package source
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/git"
+	"github.com/rancher/terraform-controller/pkg/oci"
+)
+
+const forcedGit = "git::"
+
+// Auth holds the credentials for the sources that need them
+type Auth struct {
+	Git *git.Auth
+	OCI oci.Auth
+}
+
+// Fetch writes the module content into dest
+func Fetch(ctx context.Context, content v1.ModuleContent, auth Auth, dest string) error {
+	switch {
+	case len(content.Content) > 0:
+		return writeContent(content.Content, dest)
+	case content.Registry != nil:
+		if content.Registry.Source == "" {
+			return fmt.Errorf("registry module %s has not been resolved", content.Registry.Name)
+		}
+		return Get(ctx, content.Registry.Source, "", dest, auth.Git)
+	case content.HTTP != nil:
+		return Get(ctx, content.HTTP.URL, content.HTTP.Checksum, dest, auth.Git)
+	case content.OCI != nil:
+		return getOCI(ctx, content.OCI, auth.OCI, dest)
+	default:
+		return git.CloneRepoDir(ctx, content.Git.URL, content.Git.Commit, dest, auth.Git)
+	}
+}
+
+// Get downloads a module from a source address as returned by a module registry. Git
+// ("git::" or github.com shorthand) and http(s) archive sources are supported, including
+// the "//subdir" and "?ref=" and "?checksum=" conventions. Git sources are cloned with gitAuth.
+func Get(ctx context.Context, address, checksum, dest string, gitAuth *git.Auth) error {
+	if gitAuth == nil {
+		gitAuth = &git.Auth{}
+	}
+
+	src, subdir := splitSubdir(address)
+
+	tmp, err := ioutil.TempDir("", "module-source")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	switch {
+	case strings.HasPrefix(src, forcedGit):
+		err = getGit(ctx, strings.TrimPrefix(src, forcedGit), tmp, gitAuth)
+	case strings.HasPrefix(src, "github.com/"):
+		err = getGit(ctx, "https://"+src, tmp, gitAuth)
+	case strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://"):
+		err = getArchive(ctx, src, checksum, tmp)
+	default:
+		err = fmt.Errorf("unsupported module source %q", address)
+	}
+	if err != nil {
+		return err
+	}
+
+	root, err := securePath(tmp, subdir)
+	if err != nil {
+		return err
+	}
+	return copyDir(root, dest)
+}
+
+func getGit(ctx context.Context, src, dest string, auth *git.Auth) error {
+	u, err := url.Parse(src)
+	if err != nil || u.Scheme == "" {
+		// scp style, e.g. git@github.com:org/repo.git?ref=v1
+		ref := ""
+		if i := strings.Index(src, "?ref="); i > -1 {
+			src, ref = src[:i], src[i+len("?ref="):]
+		}
+		return git.CloneRepoDir(ctx, src, refOrHead(ref), dest, auth)
+	}
+
+	query := u.Query()
+	ref := query.Get("ref")
+	query.Del("ref")
+	u.RawQuery = query.Encode()
+
+	return git.CloneRepoDir(ctx, u.String(), refOrHead(ref), dest, auth)
+}
+
+func refOrHead(ref string) string {
+	if ref == "" {
+		return "HEAD"
+	}
+	return ref
+}
+
+// splitSubdir separates the "//subdir" part of a source address, keeping the query on the source
+func splitSubdir(src string) (string, string) {
+	offset := 0
+	if i := strings.Index(src, "://"); i > -1 {
+		offset = i + 3
+	}
+
+	i := strings.Index(src[offset:], "//")
+	if i == -1 {
+		return src, ""
+	}
+	i += offset
+
+	subdir := src[i+2:]
+	src = src[:i]
+	if q := strings.Index(subdir, "?"); q > -1 {
+		src += subdir[q:]
+		subdir = subdir[:q]
+	}
+	return src, subdir
+}
+
+func writeContent(content map[string]string, dest string) error {
+	for name, data := range content {
+		path, err := securePath(dest, name)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// securePath joins name onto root, refusing names that would escape root
+func securePath(root, name string) (string, error) {
+	path := filepath.Join(root, filepath.FromSlash(name))
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside of the module", name)
+	}
+	return path, nil
+}
+
+func copyDir(src, dest string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+
+		switch {
+		case info.IsDir():
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if filepath.IsAbs(link) {
+				return fmt.Errorf("symlink %s points outside of the module", rel)
+			}
+			if _, err := securePath(src, filepath.Join(filepath.Dir(rel), link)); err != nil {
+				return fmt.Errorf("symlink %s points outside of the module", rel)
+			}
+			return os.Symlink(link, target)
+		default:
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return ioutil.WriteFile(target, data, info.Mode().Perm())
+		}
+	})
+}
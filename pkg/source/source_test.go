@@ -0,0 +1,139 @@
+package source
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tarball(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestSplitSubdir(t *testing.T) {
+	tests := map[string][2]string{
+		"https://example.com/m.tar.gz":                    {"https://example.com/m.tar.gz", ""},
+		"https://example.com/m.tar.gz//vpc-1.0.0":         {"https://example.com/m.tar.gz", "vpc-1.0.0"},
+		"git::https://example.com/m.git//modules/a?ref=x": {"git::https://example.com/m.git?ref=x", "modules/a"},
+		"github.com/org/repo//sub":                        {"github.com/org/repo", "sub"},
+	}
+	for address, expected := range tests {
+		src, subdir := splitSubdir(address)
+		assert.Equal(t, expected[0], src, address)
+		assert.Equal(t, expected[1], subdir, address)
+	}
+}
+
+func TestGetArchive(t *testing.T) {
+	archive := tarball(t, map[string]string{
+		"vpc-1.0.0/main.tf":        "# main",
+		"vpc-1.0.0/modules/sub.tf": "# sub",
+	})
+	sum := sha256.Sum256(archive)
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write(archive)
+	}))
+	defer server.Close()
+
+	dest, err := ioutil.TempDir("", "dest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest)
+
+	ctx := context.Background()
+	assert.Error(t, Get(ctx, server.URL+"/vpc.tar.gz", "sha256:00", dest, nil))
+
+	require.NoError(t, Get(ctx, server.URL+"/vpc.tar.gz//vpc-1.0.0", checksum, dest, nil))
+	main, err := ioutil.ReadFile(filepath.Join(dest, "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, "# main", string(main))
+	assert.FileExists(t, filepath.Join(dest, "modules", "sub.tf"))
+}
+
+func TestGetArchiveLimits(t *testing.T) {
+	archive := tarball(t, map[string]string{"main.tf": strings.Repeat("#", 4096)})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write(archive)
+	}))
+	defer server.Close()
+
+	dest, err := ioutil.TempDir("", "dest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest)
+
+	defer func(archive, extracted int64) {
+		maxArchiveSize, maxExtractedSize = archive, extracted
+	}(maxArchiveSize, maxExtractedSize)
+
+	ctx := context.Background()
+	maxArchiveSize = int64(len(archive) - 1)
+	assert.EqualError(t, Get(ctx, server.URL+"/m.tar.gz", "", dest, nil), fmt.Sprintf("module archive is larger than %d bytes", maxArchiveSize))
+
+	maxArchiveSize, maxExtractedSize = int64(len(archive)), 4095
+	assert.EqualError(t, Get(ctx, server.URL+"/m.tar.gz", "", dest, nil), "module archive extracts to more than 4095 bytes")
+
+	maxExtractedSize = 4096
+	assert.NoError(t, Get(ctx, server.URL+"/m.tar.gz", "", dest, nil))
+}
+
+func TestCopyDirSymlinks(t *testing.T) {
+	src, err := ioutil.TempDir("", "src")
+	require.NoError(t, err)
+	defer os.RemoveAll(src)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "modules"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src, "main.tf"), []byte("# main"), 0644))
+	require.NoError(t, os.Symlink("../main.tf", filepath.Join(src, "modules", "main.tf")))
+
+	dest, err := ioutil.TempDir("", "dest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest)
+	require.NoError(t, copyDir(src, dest))
+	link, err := os.Readlink(filepath.Join(dest, "modules", "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, "../main.tf", link)
+
+	for _, target := range []string{"../../secret", "/etc/passwd"} {
+		require.NoError(t, os.Remove(filepath.Join(src, "modules", "main.tf")))
+		require.NoError(t, os.Symlink(target, filepath.Join(src, "modules", "main.tf")))
+
+		dest, err := ioutil.TempDir("", "dest")
+		require.NoError(t, err)
+		defer os.RemoveAll(dest)
+		assert.Error(t, copyDir(src, dest), target)
+	}
+}
+
+func TestWriteContentOutsideModule(t *testing.T) {
+	dest, err := ioutil.TempDir("", "dest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest)
+
+	assert.Error(t, writeContent(map[string]string{"../escape.tf": ""}, dest))
+	assert.NoError(t, writeContent(map[string]string{"main.tf": "", "nested/vars.tf": ""}, dest))
+}
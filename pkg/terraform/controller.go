@@ -15,6 +15,8 @@ import (
 	core "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	coordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 func Register(
@@ -22,12 +24,16 @@ func Register(
 	modules tfv1.ModuleController,
 	states tfv1.StateController,
 	executions tfv1.ExecutionController,
+	notificationConfigs tfv1.NotificationConfigController,
 	clusterRoles rbacv1.ClusterRoleController,
 	clusterRoleBindings rbacv1.ClusterRoleBindingController,
 	secrets corev1.SecretController,
 	configMaps corev1.ConfigMapController,
 	serviceAccounts corev1.ServiceAccountController,
 	jobs batchv1.JobController,
+	leases coordinationv1.LeasesGetter,
+	recorder record.EventRecorder,
+	opts state.Options,
 ) {
 	// watch for modules
 	relatedresource.Watch(ctx, "state-module-watch",
@@ -119,15 +125,21 @@ func Register(
 		secrets,
 		configMaps,
 		serviceAccounts,
-		jobs)
+		jobs,
+		leases,
+		recorder,
+		opts)
 	states.OnChange(ctx, "states-handler", stateHandler.OnChange)
 	states.OnRemove(ctx, "states-handler", stateHandler.OnRemove)
 
-	moduleHandler := module.NewHandler(ctx, modules, secrets)
+	moduleHandler := module.NewHandler(ctx, modules, secrets, recorder)
 	modules.OnChange(ctx, "modules-handler", moduleHandler.OnChange)
 	modules.OnRemove(ctx, "modules-handler", moduleHandler.OnRemove)
 
-	executionHandler := execution.NewHandler(ctx, executions, states, modules)
+	executionHandler := execution.NewHandler(ctx, executions, states, modules, recorder)
 	executions.OnChange(ctx, "execution-handler", executionHandler.OnChange)
 	executions.OnRemove(ctx, "execution-handler", executionHandler.OnRemove)
+
+	notifier := execution.NewNotifier(ctx, executions, states, notificationConfigs, secrets)
+	executions.OnChange(ctx, "execution-notifier", notifier.OnChange)
 }
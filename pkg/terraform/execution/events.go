@@ -0,0 +1,167 @@
+package execution
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/wrangler/pkg/condition"
+	coreV1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	reasonPlanReady        = "PlanReady"
+	reasonApprovalReceived = "ApprovalReceived"
+	reasonApplyFinished    = "ApplyFinished"
+	reasonDestroyFinished  = "DestroyFinished"
+	reasonExecutionFailed  = "ExecutionFailed"
+)
+
+// event is a transition of an execution recorded as an event of its state
+type event struct {
+	// key identifies the transition among those of the execution
+	key       string
+	eventType string
+	reason    string
+	message   string
+	// time of the transition, zero if unknown
+	time time.Time
+}
+
+// recordEvents records the transitions of the execution that weren't recorded yet on its
+// state, or on the execution once the state is gone
+func (h *Handler) recordEvents(execution *v1.Execution) error {
+	var subject runtime.Object = execution
+	state, err := h.states.Cache().Get(execution.Namespace, execution.Spec.ExecutionName)
+	if err != nil && !k8sError.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		subject = state
+	}
+
+	// transitions before the controller started were recorded by its previous run, including
+	// those of executions that finished before
+	finished := finishedBefore(execution, h.started)
+	for _, e := range executionEvents(execution) {
+		if !h.firstSeen(execution, e.key) {
+			continue
+		}
+		if finished || (!e.time.IsZero() && e.time.Before(h.started)) {
+			continue
+		}
+		h.recorder.Event(subject, e.eventType, e.reason, e.message)
+	}
+	return nil
+}
+
+// firstSeen is true the first time it is called with the key for the execution
+func (h *Handler) firstSeen(execution *v1.Execution, key string) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	seen := h.recorded[execution.UID]
+	if seen == nil {
+		seen = map[string]bool{}
+		h.recorded[execution.UID] = seen
+	}
+	if seen[key] {
+		return false
+	}
+	seen[key] = true
+	return true
+}
+
+// forget drops what was recorded for a removed execution
+func (h *Handler) forget(execution *v1.Execution) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	delete(h.recorded, execution.UID)
+}
+
+// executionEvents returns the transitions the execution went through so far
+func executionEvents(execution *v1.Execution) []event {
+	var events []event
+
+	if v1.ExecutionRunConditionPlanned.IsTrue(execution) {
+		message := fmt.Sprintf("Execution %s planned", execution.Name)
+		if summary := execution.Status.PlanSummary; summary != "" {
+			message += ": " + summary
+		}
+		events = append(events, event{
+			key:       "planned",
+			eventType: coreV1.EventTypeNormal,
+			reason:    reasonPlanReady,
+			message:   message,
+			time:      conditionTime(v1.ExecutionRunConditionPlanned, execution),
+		})
+	}
+
+	if execution.Spec.Approval == nil {
+		if decision := execution.Annotations["approved"]; decision != "" {
+			events = append(events, event{
+				key:       "approved/" + decision,
+				eventType: coreV1.EventTypeNormal,
+				reason:    reasonApprovalReceived,
+				message:   fmt.Sprintf("Received approved=%s for execution %s", decision, execution.Name),
+			})
+		}
+	}
+	for i, approval := range execution.Status.Approvals {
+		events = append(events, event{
+			key:       fmt.Sprintf("approvals/%d", i),
+			eventType: coreV1.EventTypeNormal,
+			reason:    reasonApprovalReceived,
+			message:   fmt.Sprintf("Received approved=%s for execution %s from %s", approval.Decision, execution.Name, approval.User),
+			time:      approval.Time.Time,
+		})
+	}
+
+	if v1.ExecutionRunConditionApplied.IsTrue(execution) {
+		reason, message := reasonApplyFinished, fmt.Sprintf("Execution %s applied", execution.Name)
+		if execution.Labels["action"] == "destroy" {
+			reason, message = reasonDestroyFinished, fmt.Sprintf("Execution %s destroyed the resources", execution.Name)
+		}
+		events = append(events, event{
+			key:       "applied",
+			eventType: coreV1.EventTypeNormal,
+			reason:    reason,
+			message:   message,
+			time:      conditionTime(v1.ExecutionRunConditionApplied, execution),
+		})
+	}
+
+	if v1.ExecutionRunConditionFailed.IsTrue(execution) {
+		events = append(events, event{
+			key:       "failed",
+			eventType: coreV1.EventTypeWarning,
+			reason:    reasonExecutionFailed,
+			message:   fmt.Sprintf("Execution %s failed: %s", execution.Name, v1.ExecutionRunConditionFailed.GetMessage(execution)),
+			time:      conditionTime(v1.ExecutionRunConditionFailed, execution),
+		})
+	}
+	return events
+}
+
+// finishedBefore is true if the execution was applied or failed before t
+func finishedBefore(execution *v1.Execution, t time.Time) bool {
+	for _, cond := range []condition.Cond{v1.ExecutionRunConditionApplied, v1.ExecutionRunConditionFailed} {
+		if cond.IsTrue(execution) {
+			if updated := conditionTime(cond, execution); !updated.IsZero() && updated.Before(t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// conditionTime is when the condition last changed, zero if unknown
+func conditionTime(cond condition.Cond, execution *v1.Execution) time.Time {
+	t, err := time.Parse(time.RFC3339, cond.GetLastUpdated(execution))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,59 @@
+package execution
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExecutionEvents(t *testing.T) {
+	execution := &v1.Execution{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "run-abc",
+			Labels:      map[string]string{"action": "destroy"},
+			Annotations: map[string]string{"approved": "yes"},
+		},
+	}
+	assert.Len(t, executionEvents(execution), 1)
+
+	v1.ExecutionRunConditionPlanned.True(execution)
+	execution.Status.PlanSummary = "Plan: 0 to add, 0 to change, 2 to destroy."
+	v1.ExecutionRunConditionApplied.True(execution)
+
+	events := executionEvents(execution)
+	var reasons []string
+	for _, e := range events {
+		reasons = append(reasons, e.reason)
+	}
+	assert.Equal(t, []string{reasonPlanReady, reasonApprovalReceived, reasonDestroyFinished}, reasons)
+	assert.Equal(t, "Execution run-abc planned: Plan: 0 to add, 0 to change, 2 to destroy.", events[0].message)
+	assert.Equal(t, "approved/yes", events[1].key)
+	assert.False(t, events[2].time.IsZero())
+
+	// approvals recorded by the webhook replace the annotation
+	execution.Spec.Approval = &v1.ApprovalPolicy{}
+	execution.Status.Approvals = []v1.Approval{{User: "alice", Decision: "no", Time: metav1.Now()}}
+	v1.ExecutionRunConditionFailed.True(execution)
+	v1.ExecutionRunConditionFailed.Message(execution, "denied by alice")
+
+	events = executionEvents(execution)
+	assert.Len(t, events, 4)
+	assert.Equal(t, "approvals/0", events[1].key)
+	assert.Equal(t, "Received approved=no for execution run-abc from alice", events[1].message)
+	assert.Equal(t, coreV1.EventTypeWarning, events[3].eventType)
+	assert.Equal(t, "Execution run-abc failed: denied by alice", events[3].message)
+}
+
+func TestFinishedBefore(t *testing.T) {
+	execution := &v1.Execution{}
+	v1.ExecutionRunConditionPlanned.True(execution)
+	assert.False(t, finishedBefore(execution, time.Now().Add(time.Hour)))
+
+	v1.ExecutionRunConditionApplied.True(execution)
+	assert.True(t, finishedBefore(execution, time.Now().Add(time.Hour)))
+	assert.False(t, finishedBefore(execution, time.Now().Add(-time.Hour)))
+}
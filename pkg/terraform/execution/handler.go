@@ -2,17 +2,26 @@ package execution
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
 	tfv1 "github.com/rancher/terraform-controller/pkg/generated/controllers/terraformcontroller.cattle.io/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 )
 
-func NewHandler(ctx context.Context, executions tfv1.ExecutionController, states tfv1.StateController, modules tfv1.ModuleController) *Handler {
+func NewHandler(ctx context.Context, executions tfv1.ExecutionController, states tfv1.StateController, modules tfv1.ModuleController,
+	recorder record.EventRecorder) *Handler {
 	return &Handler{
 		ctx:        ctx,
 		states:     states,
 		executions: executions,
 		modules:    modules,
+		recorder:   recorder,
+		started:    time.Now().Truncate(time.Second),
+		recorded:   map[types.UID]map[string]bool{},
 	}
 }
 
@@ -21,6 +30,13 @@ type Handler struct {
 	executions tfv1.ExecutionController
 	states     tfv1.StateController
 	modules    tfv1.ModuleController
+	recorder   record.EventRecorder
+	// started is when the controller started, transitions before were already recorded
+	started time.Time
+
+	lock sync.Mutex
+	// recorded are the keys of the transitions recorded by execution
+	recorded map[types.UID]map[string]bool
 }
 
 func (h *Handler) OnChange(key string, execution *v1.Execution) (*v1.Execution, error) {
@@ -30,9 +46,14 @@ func (h *Handler) OnChange(key string, execution *v1.Execution) (*v1.Execution,
 
 	h.states.Enqueue(execution.Namespace, execution.Labels["state"])
 
+	if err := h.recordEvents(execution); err != nil {
+		logrus.Errorf("Error recording events of execution %s: %v", key, err)
+	}
+
 	return execution, nil
 }
 
 func (h *Handler) OnRemove(key string, execution *v1.Execution) (*v1.Execution, error) {
+	h.forget(execution)
 	return execution, nil
 }
@@ -0,0 +1,270 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	tfv1 "github.com/rancher/terraform-controller/pkg/generated/controllers/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/notification"
+	"github.com/rancher/wrangler/pkg/condition"
+	corev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// sendTimeout bounds each notification sent to a sink
+	sendTimeout = 30 * time.Second
+	// notifyWorkers send the queued deliveries, at most notifyQueueSize wait to be sent
+	notifyWorkers   = 4
+	notifyQueueSize = 256
+)
+
+// eventConditions are the conditions whose transition to true notifies about the event
+var eventConditions = map[string]condition.Cond{
+	notification.EventPlanned:          v1.ExecutionRunConditionPlanned,
+	notification.EventAwaitingApproval: v1.ExecutionRunConditionAwaitingApproval,
+	notification.EventApplied:          v1.ExecutionRunConditionApplied,
+	notification.EventFailed:           v1.ExecutionRunConditionFailed,
+}
+
+func NewNotifier(ctx context.Context, executions tfv1.ExecutionController, states tfv1.StateController,
+	configs tfv1.NotificationConfigController, secrets corev1.SecretController) *Notifier {
+	n := &Notifier{
+		ctx:        ctx,
+		executions: executions,
+		states:     states,
+		configs:    configs,
+		secrets:    secrets,
+		deliveries: make(chan delivery, notifyQueueSize),
+	}
+	for i := 0; i < notifyWorkers; i++ {
+		go n.worker()
+	}
+	return n
+}
+
+// Notifier sends the notifications of the NotificationConfigs selecting an execution's state
+// when the execution's conditions change
+type Notifier struct {
+	ctx        context.Context
+	executions tfv1.ExecutionController
+	states     tfv1.StateController
+	configs    tfv1.NotificationConfigController
+	secrets    corev1.SecretController
+	deliveries chan delivery
+}
+
+func (n *Notifier) OnChange(key string, execution *v1.Execution) (*v1.Execution, error) {
+	if execution == nil {
+		return nil, nil
+	}
+
+	events := pendingEvents(execution)
+	if len(events) == 0 {
+		return execution, nil
+	}
+	configs, err := n.configs.Cache().List(execution.Namespace, labels.Everything())
+	if err != nil || len(configs) == 0 {
+		return execution, err
+	}
+
+	stateLabels := labels.Set{}
+	state, err := n.states.Cache().Get(execution.Namespace, execution.Spec.ExecutionName)
+	if err != nil && !k8sError.IsNotFound(err) {
+		return execution, err
+	}
+	if err == nil {
+		stateLabels = state.Labels
+	}
+
+	// the events are recorded before they are sent, so a failed update never sends them twice.
+	// Failed sends are logged rather than retried for the same reason.
+	execution = execution.DeepCopy()
+	execution.Status.Notified = append(execution.Status.Notified, events...)
+	execution, err = n.executions.Update(execution)
+	if err != nil {
+		return execution, err
+	}
+
+	for _, event := range events {
+		msg := notification.New(event, execution)
+		for _, config := range configs {
+			ok, err := selects(config, stateLabels, event, execution)
+			if err != nil {
+				logrus.Errorf("Error selecting notification config %s/%s: %v", config.Namespace, config.Name, err)
+				continue
+			}
+			if ok {
+				n.enqueue(delivery{config: config, msg: msg})
+			}
+		}
+	}
+	return execution, nil
+}
+
+// enqueue queues the delivery for the notify workers. Sinks may take up to sendTimeout each, so
+// they are sent to outside of the worker shared by the execution handlers. When the queue is
+// full the delivery is dropped rather than holding up the handler.
+func (n *Notifier) enqueue(d delivery) {
+	select {
+	case n.deliveries <- d:
+	default:
+		logrus.Errorf("Dropping %s notification of execution %s/%s for notification config %s, %d notifications are waiting to be sent",
+			d.msg.Event, d.msg.Namespace, d.msg.Execution, d.config.Name, notifyQueueSize)
+	}
+}
+
+// worker sends queued deliveries until the notifier's context is done
+func (n *Notifier) worker() {
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case d := <-n.deliveries:
+			n.notify(d.config, d.msg)
+		}
+	}
+}
+
+// delivery is a notification to send to the sinks of a config
+type delivery struct {
+	config *v1.NotificationConfig
+	msg    notification.Notification
+}
+
+// notify sends msg to every sink of the config
+func (n *Notifier) notify(config *v1.NotificationConfig, msg notification.Notification) {
+	for i, spec := range config.Spec.Sinks {
+		name := spec.Name
+		if name == "" {
+			name = fmt.Sprintf("sinks[%d]", i)
+		}
+		if err := n.send(config, spec, msg); err != nil {
+			logrus.Errorf("Error sending %s notification of execution %s/%s to %s of notification config %s: %v",
+				msg.Event, msg.Namespace, msg.Execution, name, config.Name, err)
+			continue
+		}
+		logrus.Debugf("Sent %s notification of execution %s/%s to %s of notification config %s",
+			msg.Event, msg.Namespace, msg.Execution, name, config.Name)
+	}
+}
+
+func (n *Notifier) send(config *v1.NotificationConfig, spec v1.NotificationSink, msg notification.Notification) error {
+	sink, err := n.sink(config.Namespace, spec)
+	if err != nil {
+		return err
+	}
+
+	text := config.Spec.Template
+	if spec.Template != "" {
+		text = spec.Template
+	}
+	message, err := notification.Render(text, msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(n.ctx, sendTimeout)
+	defer cancel()
+	return sink.Send(ctx, msg, message)
+}
+
+// sink returns the sink of spec, reading its url or credentials from secrets in the namespace
+func (n *Notifier) sink(namespace string, spec v1.NotificationSink) (notification.Sink, error) {
+	switch {
+	case spec.Webhook != nil:
+		url, err := n.url(namespace, spec.Webhook)
+		return &notification.Webhook{URL: url}, err
+	case spec.Slack != nil:
+		url, err := n.url(namespace, spec.Slack)
+		return &notification.Slack{URL: url}, err
+	case spec.SMTP != nil:
+		s := &notification.SMTP{
+			Host: spec.SMTP.Host,
+			Port: spec.SMTP.Port,
+			From: spec.SMTP.From,
+			To:   spec.SMTP.To,
+		}
+		if spec.SMTP.SecretName != "" {
+			secret, err := n.secrets.Cache().Get(namespace, spec.SMTP.SecretName)
+			if err != nil {
+				return nil, err
+			}
+			s.Username = string(secret.Data["username"])
+			s.Password = string(secret.Data["password"])
+		}
+		return s, nil
+	}
+	return nil, errors.New("sink sets none of webhook, slack or smtp")
+}
+
+func (n *Notifier) url(namespace string, webhook *v1.WebhookSink) (string, error) {
+	if webhook.SecretName == "" {
+		if webhook.URL == "" {
+			return "", errors.New("sink sets neither url nor secretName")
+		}
+		return webhook.URL, nil
+	}
+
+	secret, err := n.secrets.Cache().Get(namespace, webhook.SecretName)
+	if err != nil {
+		return "", err
+	}
+	url := string(secret.Data["url"])
+	if url == "" {
+		return "", fmt.Errorf("secret %s/%s has no url key", namespace, webhook.SecretName)
+	}
+	return url, nil
+}
+
+// pendingEvents returns the events whose condition is true on the execution and that weren't
+// notified yet
+func pendingEvents(execution *v1.Execution) []string {
+	var events []string
+	for _, event := range notification.Events {
+		if eventConditions[event].IsTrue(execution) && !contains(execution.Status.Notified, event) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// selects is true if the config notifies about the event of the execution of a state with
+// stateLabels. Events from before the config was created are skipped, so creating a config
+// doesn't notify about every past execution.
+func selects(config *v1.NotificationConfig, stateLabels labels.Set, event string, execution *v1.Execution) (bool, error) {
+	if len(config.Spec.Events) > 0 && !contains(config.Spec.Events, event) {
+		return false, nil
+	}
+
+	if updated, err := time.Parse(time.RFC3339, eventConditions[event].GetLastUpdated(execution)); err == nil &&
+		updated.Before(config.CreationTimestamp.Time.Truncate(time.Second)) {
+		return false, nil
+	}
+
+	if config.Spec.StateSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(config.Spec.StateSelector)
+		if err != nil {
+			return false, err
+		}
+		if !selector.Matches(stateLabels) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package execution
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/notification"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestPendingEvents(t *testing.T) {
+	execution := &v1.Execution{}
+	assert.Empty(t, pendingEvents(execution))
+
+	v1.ExecutionRunConditionPlanned.True(execution)
+	v1.ExecutionRunConditionAwaitingApproval.True(execution)
+	assert.Equal(t, []string{notification.EventPlanned, notification.EventAwaitingApproval}, pendingEvents(execution))
+
+	execution.Status.Notified = []string{notification.EventPlanned}
+	v1.ExecutionRunConditionApplied.False(execution)
+	assert.Equal(t, []string{notification.EventAwaitingApproval}, pendingEvents(execution))
+}
+
+func TestSelects(t *testing.T) {
+	execution := &v1.Execution{}
+	v1.ExecutionRunConditionFailed.True(execution)
+
+	config := &v1.NotificationConfig{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour))},
+		Spec: v1.NotificationConfigSpec{
+			StateSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+			Events:        []string{notification.EventFailed},
+		},
+	}
+
+	ok, err := selects(config, labels.Set{"team": "platform"}, notification.EventFailed, execution)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = selects(config, labels.Set{"team": "web"}, notification.EventFailed, execution)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = selects(config, labels.Set{"team": "platform"}, notification.EventApplied, execution)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// the execution failed before the config existed
+	config.CreationTimestamp = metav1.NewTime(time.Now().Add(time.Hour))
+	ok, err = selects(config, labels.Set{"team": "platform"}, notification.EventFailed, execution)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestEnqueueDropsWhenFull(t *testing.T) {
+	n := &Notifier{deliveries: make(chan delivery, 1)}
+	d := delivery{config: &v1.NotificationConfig{}, msg: notification.Notification{Event: notification.EventApplied}}
+	n.enqueue(d)
+	n.enqueue(d)
+	assert.Len(t, n.deliveries, 1)
+}
@@ -0,0 +1,24 @@
+package module
+
+import (
+	"time"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/interval"
+)
+
+// SetDefaults fills in the unset fields of the module's spec that have defaults. The admission
+// webhook sets them when modules are stored, the handler when it isn't installed.
+func SetDefaults(module *v1.Module) {
+	defaultInterval := int(interval.DefaultInterval / time.Second)
+	// content and http modules are requeued with the git interval
+	if module.Spec.Git.IntervalSeconds == 0 {
+		module.Spec.Git.IntervalSeconds = defaultInterval
+	}
+	if module.Spec.Registry != nil && module.Spec.Registry.IntervalSeconds == 0 {
+		module.Spec.Registry.IntervalSeconds = defaultInterval
+	}
+	if module.Spec.OCI != nil && module.Spec.OCI.IntervalSeconds == 0 {
+		module.Spec.OCI.IntervalSeconds = defaultInterval
+	}
+}
@@ -11,40 +11,58 @@ import (
 	"github.com/rancher/terraform-controller/pkg/git"
 	"github.com/rancher/terraform-controller/pkg/interval"
 	corev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	coreV1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 )
 
-func NewHandler(ctx context.Context, modules tfv1.ModuleController, secrets corev1.SecretController) *Handler {
+func NewHandler(ctx context.Context, modules tfv1.ModuleController, secrets corev1.SecretController, recorder record.EventRecorder) *Handler {
 	return &Handler{
-		ctx:     ctx,
-		modules: modules,
-		secrets: secrets,
+		ctx:      ctx,
+		modules:  modules,
+		secrets:  secrets,
+		recorder: recorder,
 	}
 }
 
 type Handler struct {
-	ctx     context.Context
-	modules tfv1.ModuleController
-	secrets corev1.SecretController
+	ctx      context.Context
+	modules  tfv1.ModuleController
+	secrets  corev1.SecretController
+	recorder record.EventRecorder
 }
 
 func (h *Handler) OnChange(key string, module *v1.Module) (*v1.Module, error) {
 	if module == nil {
 		return nil, nil
 	}
-	if module.Spec.Git.IntervalSeconds == 0 {
-		module.Spec.Git.IntervalSeconds = int(interval.DefaultInterval / time.Second)
-	}
+	SetDefaults(module)
 
 	if isPolling(module.Spec) && needsUpdate(module) {
 		return h.updateCommit(key, module)
 	}
+	if isRegistry(module.Spec) && needsRegistryUpdate(module) {
+		return h.updateRegistry(module)
+	}
+	if isOCI(module.Spec) && needsOCIUpdate(module) {
+		return h.updateOCI(module)
+	}
+	if len(module.Spec.Content) == 0 && module.Spec.HTTP != nil && module.Spec.HTTP.Checksum == "" {
+		return h.failed(module, reasonInvalidSource, errors.New("http module source requires a checksum"))
+	}
 	hash := computeHash(module)
 	if module.Status.ContentHash != hash {
 		return h.updateHash(module, hash)
 	}
+	if needsValidation(module) {
+		return h.validateContent(module)
+	}
+
+	module.Status.ObservedGeneration = module.Generation
+	module.Status.Phase = phase(module)
 
-	h.modules.EnqueueAfter(module.Namespace, module.Name, time.Duration(module.Spec.Git.IntervalSeconds)*time.Second)
+	h.modules.EnqueueAfter(module.Namespace, module.Name, pollInterval(module.Spec))
 
 	return h.modules.Update(module)
 }
@@ -56,14 +74,70 @@ func (h *Handler) OnRemove(key string, module *v1.Module) (*v1.Module, error) {
 
 func (h *Handler) updateHash(module *v1.Module, hash string) (*v1.Module, error) {
 	module = module.DeepCopy()
-	module.Status.Content = module.Spec.ModuleContent
-	module.Status.ContentHash = hash
+	content := module.Spec.ModuleContent
 	if isPolling(module.Spec) && module.Status.GitChecked != nil {
-		module.Status.Content.Git.Commit = module.Status.GitChecked.Commit
+		content.Git.Tag = module.Status.GitChecked.Tag
+		content.Git.Commit = module.Status.GitChecked.Commit
 	}
+	if isRegistry(module.Spec) && module.Status.RegistryChecked != nil {
+		content.Registry = module.Status.RegistryChecked.DeepCopy()
+	}
+	if isOCI(module.Spec) && module.Spec.OCI.Digest == "" && module.Status.OCIChecked != nil {
+		content.OCI = module.Status.OCIChecked.DeepCopy()
+	}
+
+	if needsVerification(module.Spec) {
+		if rejected(module, content.Git.Commit) {
+			h.modules.EnqueueAfter(module.Namespace, module.Name, pollInterval(module.Spec))
+			return module, nil
+		}
+		err := h.verifyCommit(module.Namespace, module.Spec, content.Git.Commit)
+		v1.ModuleConditionVerified.SetError(module, reasonVerificationFailed, err)
+		if err != nil {
+			// keep the last verified content until the checked commit or the spec changes
+			logrus.Errorf("module %s/%s failed verification: %v", module.Namespace, module.Name, err)
+			h.recorder.Eventf(module, coreV1.EventTypeWarning, reasonVerificationFailed, "Commit %s failed verification: %v", content.Git.Commit, err)
+			h.modules.EnqueueAfter(module.Namespace, module.Name, pollInterval(module.Spec))
+			module.Status.LastError = err.Error()
+			module.Status.RejectedCommit = content.Git.Commit
+			module.Status.Phase = v1.ModulePhaseFailed
+			module.Status.ObservedGeneration = module.Generation
+			return h.modules.Update(module)
+		}
+	}
+
+	reason, message := contentEvent(module.Status.Content, content)
+	h.recorder.Event(module, coreV1.EventTypeNormal, reason, message)
+
+	module.Status.Content = content
+	module.Status.ContentHash = hash
+	module.Status.LastError = ""
+	module.Status.RejectedCommit = ""
+	module.Status.ObservedGeneration = module.Generation
+	module.Status.Phase = phase(module)
 	return h.modules.Update(module)
 }
 
+func (h *Handler) verifyCommit(ns string, spec v1.ModuleSpec, commit string) error {
+	name := spec.Git.Verification.SecretName
+	secret, err := h.secrets.Get(ns, name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "fetch verification secret %s", name)
+	}
+
+	keys, err := git.KeysFromSecret(secret.Data)
+	if err != nil {
+		return err
+	}
+
+	auth, err := h.getAuth(ns, spec)
+	if err != nil {
+		return err
+	}
+
+	return git.VerifyCommit(h.ctx, spec.Git.URL, commit, &auth, keys)
+}
+
 func (h *Handler) updateCommit(key string, module *v1.Module) (*v1.Module, error) {
 	branch := module.Spec.Git.Branch
 	tag := module.Spec.Git.Tag
@@ -78,22 +152,28 @@ func (h *Handler) updateCommit(key string, module *v1.Module) (*v1.Module, error
 
 	auth, err := h.getAuth(module.Namespace, module.Spec)
 	if err != nil {
-		return nil, err
+		return h.checkFailed(module, v1.ModuleConditionGitUpdated, reasonAuthFailed, err)
 	}
 
-	commit, err := git.GetCommit(h.ctx, module.Spec.Git.URL, branch, tag, &auth)
-	if err != nil {
-		return nil, err
+	var commit string
+	if module.Spec.Git.Semver != "" {
+		tag, commit, err = git.LatestTag(h.ctx, module.Spec.Git.URL, module.Spec.Git.Semver, &auth)
+		if err != nil {
+			return h.checkFailed(module, v1.ModuleConditionGitUpdated, reasonLatestTagFailed, err)
+		}
+	} else {
+		commit, err = git.GetCommit(h.ctx, module.Spec.Git.URL, branch, tag, &auth)
+		if err != nil {
+			return h.checkFailed(module, v1.ModuleConditionGitUpdated, reasonGetCommitFailed, err)
+		}
 	}
 
 	gitChecked := module.Spec.Git
+	gitChecked.Tag = tag
 	gitChecked.Commit = commit
 	module.Status.GitChecked = &gitChecked
-	module.Status.CheckTime = metav1.Now()
-
-	v1.ModuleConditionGitUpdated.True(module)
 
-	return h.modules.Update(module)
+	return h.checkSucceeded(module, v1.ModuleConditionGitUpdated)
 }
 
 func (h *Handler) getAuth(ns string, spec v1.ModuleSpec) (git.Auth, error) {
@@ -117,11 +197,41 @@ func needsUpdate(m *v1.Module) bool {
 		v1.ModuleConditionGitUpdated.IsFalse(m) ||
 		m.Status.GitChecked == nil ||
 		m.Status.GitChecked.URL != m.Spec.Git.URL ||
-		m.Status.GitChecked.Branch != m.Spec.Git.Branch
+		m.Status.GitChecked.Branch != m.Spec.Git.Branch ||
+		m.Status.GitChecked.Semver != m.Spec.Git.Semver
+}
+
+// pollInterval is how often the module source is checked for updates
+func pollInterval(spec v1.ModuleSpec) time.Duration {
+	if isRegistry(spec) {
+		return time.Duration(spec.Registry.IntervalSeconds) * time.Second
+	}
+	if isOCI(spec) {
+		return time.Duration(spec.OCI.IntervalSeconds) * time.Second
+	}
+	return time.Duration(spec.Git.IntervalSeconds) * time.Second
+}
+
+// rejected is true if the commit already failed verification with the current spec
+func rejected(module *v1.Module, commit string) bool {
+	return commit != "" && commit == module.Status.RejectedCommit && module.Status.ObservedGeneration == module.Generation
+}
+
+func needsVerification(spec v1.ModuleSpec) bool {
+	return len(spec.Content) == 0 &&
+		spec.Registry == nil &&
+		spec.HTTP == nil &&
+		spec.OCI == nil &&
+		spec.Git.URL != "" &&
+		spec.Git.Verification != nil &&
+		spec.Git.Verification.SecretName != ""
 }
 
 func isPolling(spec v1.ModuleSpec) bool {
 	return len(spec.Content) == 0 &&
+		spec.Registry == nil &&
+		spec.HTTP == nil &&
+		spec.OCI == nil &&
 		spec.Git.URL != "" &&
 		spec.Git.Commit == ""
 }
@@ -131,6 +241,45 @@ func computeHash(obj *v1.Module) string {
 		return digest.SHA256Map(obj.Spec.Content)
 	}
 
+	if isRegistry(obj.Spec) {
+		if obj.Status.RegistryChecked == nil {
+			return ""
+		}
+		checked := obj.Status.RegistryChecked
+		return digest.SHA256Map(map[string]string{
+			"host":      checked.Host,
+			"namespace": checked.Namespace,
+			"name":      checked.Name,
+			"provider":  checked.Provider,
+			"version":   checked.ResolvedVersion,
+			"source":    checked.Source,
+		})
+	}
+
+	if isOCI(obj.Spec) {
+		location := obj.Spec.OCI
+		if location.Digest == "" && obj.Status.OCIChecked != nil {
+			location = obj.Status.OCIChecked
+		}
+		if location.Digest == "" {
+			return ""
+		}
+		return digest.SHA256Map(map[string]string{
+			"reference": location.Reference,
+			"digest":    location.Digest,
+		})
+	}
+
+	if http := obj.Spec.HTTP; http != nil {
+		if http.URL == "" || http.Checksum == "" {
+			return ""
+		}
+		return digest.SHA256Map(map[string]string{
+			"url":      http.URL,
+			"checksum": http.Checksum,
+		})
+	}
+
 	git := obj.Spec.Git
 	if git.URL == "" {
 		return ""
@@ -0,0 +1,59 @@
+package module
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	tfv1 "github.com/rancher/terraform-controller/pkg/generated/controllers/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+type fakeModules struct {
+	tfv1.ModuleController
+}
+
+func (fakeModules) Update(module *v1.Module) (*v1.Module, error) {
+	return module, nil
+}
+
+func TestRejected(t *testing.T) {
+	module := &v1.Module{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+	assert.False(t, rejected(module, ""))
+
+	module.Status.RejectedCommit = "abc"
+	module.Status.ObservedGeneration = 2
+	assert.True(t, rejected(module, "abc"))
+	assert.False(t, rejected(module, "def"))
+
+	// a spec change, e.g. new trusted keys, verifies the commit again
+	module.Generation = 3
+	assert.False(t, rejected(module, "abc"))
+}
+
+func TestValidateContentAfterFetchFailure(t *testing.T) {
+	h := &Handler{
+		ctx:      context.Background(),
+		modules:  fakeModules{},
+		recorder: record.NewFakeRecorder(10),
+	}
+	module := &v1.Module{}
+	module.Status.ContentHash = "hash"
+	module.Status.Content.Content = map[string]string{"../main.tf": ""}
+
+	_, err := h.validateContent(module)
+	require.Error(t, err)
+	assert.Equal(t, v1.ModulePhaseFailed, module.Status.Phase)
+	assert.NotEmpty(t, module.Status.LastError)
+
+	module.Status.Content.Content = map[string]string{"main.tf": "variable \"name\" {}\n"}
+	module, err = h.validateContent(module)
+	require.NoError(t, err)
+	assert.Equal(t, v1.ModulePhaseReady, module.Status.Phase)
+	assert.Empty(t, module.Status.LastError)
+	assert.Equal(t, "hash", module.Status.ValidatedHash)
+	assert.Len(t, module.Status.Variables, 1)
+}
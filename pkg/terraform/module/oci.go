@@ -0,0 +1,73 @@
+package module
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/interval"
+	"github.com/rancher/terraform-controller/pkg/oci"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// updateOCI resolves the tag of an OCI module to the digest it currently points to
+func (h *Handler) updateOCI(module *v1.Module) (*v1.Module, error) {
+	location := module.Spec.OCI
+
+	ref, err := oci.ParseReference(location.Reference)
+	if err != nil {
+		return h.checkFailed(module, v1.ModuleConditionOCIUpdated, reasonResolveFailed, err)
+	}
+
+	auth, err := h.getOCIAuth(module.Namespace, location, ref)
+	if err != nil {
+		return h.checkFailed(module, v1.ModuleConditionOCIUpdated, reasonAuthFailed, err)
+	}
+
+	tag := location.Tag
+	if tag == "" {
+		tag = "latest"
+	}
+
+	digest, err := oci.NewClient(auth, location.PlainHTTP).Resolve(h.ctx, ref, tag)
+	if err != nil {
+		return h.checkFailed(module, v1.ModuleConditionOCIUpdated, reasonResolveFailed, err)
+	}
+
+	checked := location.DeepCopy()
+	checked.Digest = digest
+	module.Status.OCIChecked = checked
+
+	return h.checkSucceeded(module, v1.ModuleConditionOCIUpdated)
+}
+
+func (h *Handler) getOCIAuth(ns string, location *v1.OCILocation, ref oci.Reference) (oci.Auth, error) {
+	if location.SecretName == "" {
+		return oci.Auth{}, nil
+	}
+
+	secret, err := h.secrets.Get(ns, location.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return oci.Auth{}, errors.Wrapf(err, "fetch pull secret %s", location.SecretName)
+	}
+
+	return oci.FromSecret(secret.Data, ref.Host)
+}
+
+// needsOCIUpdate is true when a tag needs to be resolved, pinned digests are never polled
+func needsOCIUpdate(m *v1.Module) bool {
+	checked := m.Status.OCIChecked
+	spec := m.Spec.OCI
+	if spec.Digest != "" {
+		return false
+	}
+	return interval.NeedsUpdate(m.Status.CheckTime.Time, time.Duration(spec.IntervalSeconds)*time.Second) ||
+		v1.ModuleConditionOCIUpdated.IsFalse(m) ||
+		checked == nil ||
+		checked.Reference != spec.Reference ||
+		checked.Tag != spec.Tag
+}
+
+func isOCI(spec v1.ModuleSpec) bool {
+	return len(spec.Content) == 0 && spec.OCI != nil
+}
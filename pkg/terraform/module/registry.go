@@ -0,0 +1,59 @@
+package module
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/interval"
+	"github.com/rancher/terraform-controller/pkg/registry"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (h *Handler) updateRegistry(module *v1.Module) (*v1.Module, error) {
+	location := module.Spec.Registry
+
+	token := ""
+	if location.SecretName != "" {
+		secret, err := h.secrets.Get(module.Namespace, location.SecretName, metav1.GetOptions{})
+		if err != nil {
+			err = errors.Wrapf(err, "fetch registry secret %s", location.SecretName)
+			return h.checkFailed(module, v1.ModuleConditionRegistryUpdated, reasonAuthFailed, err)
+		}
+		token = string(secret.Data[registry.TokenKey])
+	}
+
+	resolved, err := registry.NewClient(token).Resolve(h.ctx, registry.Module{
+		Host:      location.Host,
+		Namespace: location.Namespace,
+		Name:      location.Name,
+		Provider:  location.Provider,
+	}, location.Version)
+	if err != nil {
+		return h.checkFailed(module, v1.ModuleConditionRegistryUpdated, reasonResolveFailed, err)
+	}
+
+	checked := location.DeepCopy()
+	checked.ResolvedVersion = resolved.Version
+	checked.Source = resolved.Source
+	module.Status.RegistryChecked = checked
+
+	return h.checkSucceeded(module, v1.ModuleConditionRegistryUpdated)
+}
+
+func needsRegistryUpdate(m *v1.Module) bool {
+	checked := m.Status.RegistryChecked
+	spec := m.Spec.Registry
+	return interval.NeedsUpdate(m.Status.CheckTime.Time, time.Duration(spec.IntervalSeconds)*time.Second) ||
+		v1.ModuleConditionRegistryUpdated.IsFalse(m) ||
+		checked == nil ||
+		checked.Host != spec.Host ||
+		checked.Namespace != spec.Namespace ||
+		checked.Name != spec.Name ||
+		checked.Provider != spec.Provider ||
+		checked.Version != spec.Version
+}
+
+func isRegistry(spec v1.ModuleSpec) bool {
+	return len(spec.Content) == 0 && spec.Registry != nil
+}
@@ -0,0 +1,87 @@
+package module
+
+import (
+	"fmt"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/sirupsen/logrus"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	reasonAuthFailed         = "AuthFailed"
+	reasonCommitAdvanced     = "CommitAdvanced"
+	reasonContentUpdated     = "ContentUpdated"
+	reasonFetchFailed        = "FetchFailed"
+	reasonGetCommitFailed    = "GetCommitFailed"
+	reasonInvalid            = "Invalid"
+	reasonInvalidSource      = "InvalidSource"
+	reasonLatestTagFailed    = "LatestTagFailed"
+	reasonResolveFailed      = "ResolveFailed"
+	reasonVerificationFailed = "VerificationFailed"
+)
+
+// checkSucceeded records a successful check of the module source and clears the last error
+func (h *Handler) checkSucceeded(module *v1.Module, cond condition.Cond) (*v1.Module, error) {
+	now := metav1.Now()
+	module.Status.CheckTime = now
+	module.Status.LastSuccessfulCheck = now
+	module.Status.LastError = ""
+	module.Status.ObservedGeneration = module.Generation
+	module.Status.Phase = phase(module)
+	cond.SetError(module, "", nil)
+
+	return h.modules.Update(module)
+}
+
+// checkFailed records why the module source could not be checked, the error is returned
+// so the module is retried with backoff
+func (h *Handler) checkFailed(module *v1.Module, cond condition.Cond, reason string, err error) (*v1.Module, error) {
+	cond.SetError(module, reason, err)
+	return h.failed(module, reason, err)
+}
+
+func (h *Handler) failed(module *v1.Module, reason string, err error) (*v1.Module, error) {
+	logrus.Errorf("module %s/%s: %v", module.Namespace, module.Name, err)
+	h.recorder.Event(module, coreV1.EventTypeWarning, reason, err.Error())
+
+	module.Status.LastError = err.Error()
+	module.Status.ObservedGeneration = module.Generation
+	module.Status.Phase = v1.ModulePhaseFailed
+	if _, updateErr := h.modules.Update(module); updateErr != nil {
+		return nil, updateErr
+	}
+	return nil, err
+}
+
+func phase(module *v1.Module) string {
+	switch {
+	case module.Status.LastError != "", v1.ModuleConditionValid.IsFalse(module):
+		return v1.ModulePhaseFailed
+	case module.Status.ContentHash == "":
+		return v1.ModulePhasePending
+	default:
+		return v1.ModulePhaseReady
+	}
+}
+
+// contentEvent returns the reason and message of the event recorded when the module's content
+// changes from old to content
+func contentEvent(old, content v1.ModuleContent) (string, string) {
+	switch {
+	case content.Git.Commit != "" && content.Git.Commit != old.Git.Commit:
+		message := fmt.Sprintf("Advanced to commit %s", content.Git.Commit)
+		if content.Git.Tag != "" {
+			message += fmt.Sprintf(" of tag %s", content.Git.Tag)
+		}
+		return reasonCommitAdvanced, message
+	case content.Registry != nil && content.Registry.ResolvedVersion != "" &&
+		(old.Registry == nil || old.Registry.ResolvedVersion != content.Registry.ResolvedVersion):
+		return reasonContentUpdated, fmt.Sprintf("Advanced to version %s of %s", content.Registry.ResolvedVersion, content.Registry.Source)
+	case content.OCI != nil && content.OCI.Digest != "" && (old.OCI == nil || old.OCI.Digest != content.OCI.Digest):
+		return reasonContentUpdated, fmt.Sprintf("Advanced to %s@%s", content.OCI.Reference, content.OCI.Digest)
+	}
+	return reasonContentUpdated, "Module content changed"
+}
@@ -0,0 +1,95 @@
+package module
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/rancher/terraform-controller/pkg/oci"
+	"github.com/rancher/terraform-controller/pkg/source"
+	"github.com/rancher/terraform-controller/pkg/validate"
+	"github.com/sirupsen/logrus"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+// validateContent fetches the resolved module content and parses it, recording the
+// declared variables and outputs and whether the content is valid
+func (h *Handler) validateContent(module *v1.Module) (*v1.Module, error) {
+	dir, err := ioutil.TempDir("", "module-validate")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	auth, err := h.sourceAuth(module)
+	if err != nil {
+		return h.failed(module, reasonFetchFailed, err)
+	}
+
+	if err := source.Fetch(h.ctx, module.Status.Content, auth, dir); err != nil {
+		return h.failed(module, reasonFetchFailed, errors.Wrap(err, "fetching module content"))
+	}
+
+	result, err := validate.Dir(dir)
+	v1.ModuleConditionValid.SetError(module, reasonInvalid, err)
+	module.Status.ValidatedHash = module.Status.ContentHash
+	module.Status.Variables = nil
+	module.Status.Outputs = nil
+	module.Status.Unformatted = nil
+	module.Status.RequiredVersions = nil
+
+	if err != nil {
+		logrus.Errorf("module %s/%s is not valid: %v", module.Namespace, module.Name, err)
+		h.recorder.Eventf(module, coreV1.EventTypeWarning, reasonInvalid, "Module content is not valid: %v", err)
+		module.Status.LastError = err.Error()
+	} else {
+		module.Status.LastError = ""
+		for _, v := range result.Variables {
+			module.Status.Variables = append(module.Status.Variables, v1.ModuleVariable{
+				Name:        v.Name,
+				Type:        v.Type,
+				Description: v.Description,
+				Required:    v.Required,
+				Sensitive:   v.Sensitive,
+			})
+		}
+		for _, o := range result.Outputs {
+			module.Status.Outputs = append(module.Status.Outputs, v1.ModuleOutput{
+				Name:        o.Name,
+				Description: o.Description,
+				Sensitive:   o.Sensitive,
+			})
+		}
+		module.Status.Unformatted = result.Unformatted
+		module.Status.RequiredVersions = result.RequiredCore
+	}
+
+	module.Status.ObservedGeneration = module.Generation
+	module.Status.Phase = phase(module)
+	return h.modules.Update(module)
+}
+
+// sourceAuth loads the credentials needed to fetch the module content
+func (h *Handler) sourceAuth(module *v1.Module) (source.Auth, error) {
+	gitAuth, err := h.getAuth(module.Namespace, module.Spec)
+	if err != nil {
+		return source.Auth{}, err
+	}
+
+	auth := source.Auth{Git: &gitAuth}
+	if location := module.Status.Content.OCI; location != nil {
+		ref, err := oci.ParseReference(location.Reference)
+		if err != nil {
+			return auth, err
+		}
+		if auth.OCI, err = h.getOCIAuth(module.Namespace, location, ref); err != nil {
+			return auth, err
+		}
+	}
+	return auth, nil
+}
+
+func needsValidation(module *v1.Module) bool {
+	return module.Status.ContentHash != "" && module.Status.ValidatedHash != module.Status.ContentHash
+}
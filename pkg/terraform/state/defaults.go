@@ -0,0 +1,21 @@
+package state
+
+import (
+	"fmt"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+)
+
+// SetDefaults fills in the unset fields of the state's spec that have defaults. The admission
+// webhook sets them when states are stored, the handler when it isn't installed.
+func SetDefaults(state *v1.State) {
+	if state.Spec.Version < 1 {
+		state.Spec.Version = 1
+	}
+	if state.Spec.Image == "" {
+		state.Spec.Image = fmt.Sprintf("%s:latest", DefaultExecutorImage)
+	}
+	if state.Spec.Approval != nil && state.Spec.Approval.Approvals < 1 {
+		state.Spec.Approval.Approvals = 1
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
@@ -29,8 +30,9 @@ type Input struct {
 	Secrets    []*coreV1.Secret
 }
 
-// deployCreate creates all resources for the job to run terraform create and returns the run name
-func (h *Handler) deployCreate(state *v1.State, input *Input) (*v1.Execution, error) {
+// deployCreate creates all resources for the job to run terraform create, or the state action
+// of an operation, and returns the run name
+func (h *Handler) deployCreate(state *v1.State, input *Input, action string, op *v1.Operation) (*v1.Execution, error) {
 	runHash := createRunHash(state, input, ActionCreate)
 	jsonVars, err := json.Marshal(getCombinedVars(state, input))
 	if err != nil {
@@ -49,7 +51,7 @@ func (h *Handler) deployCreate(state *v1.State, input *Input) (*v1.Execution, er
 
 	logrus.Debugf("Create - Creating execution for %s", state.Name)
 	//skip owner reference for executions so logs stay around after deletion
-	exec, err := h.createExecution(or, state, input, runHash)
+	exec, err := h.createExecution(or, state, input, runHash, action, op)
 	if err != nil {
 		logrus.Errorf("error creating execution for %s top level %v", state.Name, err)
 		return exec, err
@@ -77,7 +79,7 @@ func (h *Handler) deployCreate(state *v1.State, input *Input) (*v1.Execution, er
 	}
 
 	logrus.Debugf("Create - Creating job for %s", state.Name)
-	job, err := h.createJob(or, input, exec.Name, runHash, ActionCreate, sa.Name, namespace, state.Spec.NodeSelector)
+	job, err := h.createJob(or, input, exec.Name, runHash, action, sa.Name, namespace, state.Spec.NodeSelector)
 	if err != nil {
 		logrus.Errorf("error creating job for %s top level %v", state.Name, err)
 		return exec, err
@@ -91,6 +93,7 @@ func (h *Handler) deployCreate(state *v1.State, input *Input) (*v1.Execution, er
 	}
 
 	logrus.Infof("Deployed create job for state %v", state.Name)
+	h.recorder.Eventf(state, coreV1.EventTypeNormal, reasonJobDeployed, "Deployed %s job %s for execution %s", action, job.Name, exec.Name)
 	return exec, nil
 }
 
@@ -112,7 +115,7 @@ func (h *Handler) deployDestroy(state *v1.State, input *Input) (*v1.Execution, e
 	}
 
 	logrus.Debug("Destroy - Creating execution")
-	exec, err := h.createExecution(or, state, input, runHash)
+	exec, err := h.createExecution(or, state, input, runHash, ActionDestroy, nil)
 	if err != nil {
 		return exec, err
 	}
@@ -148,6 +151,7 @@ func (h *Handler) deployDestroy(state *v1.State, input *Input) (*v1.Execution, e
 	}
 
 	logrus.Infof("Deployed destroy job for state %v with execution name %s", state.Name, exec.Name)
+	h.recorder.Eventf(state, coreV1.EventTypeNormal, reasonJobDeployed, "Deployed destroy job %s for execution %s", job.Name, exec.Name)
 	return exec, nil
 }
 
@@ -156,6 +160,8 @@ func (h *Handler) createExecution(
 	state *v1.State,
 	input *Input,
 	runHash string,
+	action string,
+	op *v1.Operation,
 ) (*v1.Execution, error) {
 	execution := &v1.Execution{
 		ObjectMeta: metaV1.ObjectMeta{
@@ -166,15 +172,28 @@ func (h *Handler) createExecution(
 			Labels: map[string]string{
 				"state":   state.Name,
 				"runHash": runHash,
+				"action":  action,
 			},
 		},
 		Spec: v1.ExecutionSpec{
-			ExecutionName:    state.Name,
-			AutoConfirm:      state.Spec.AutoConfirm,
-			Content:          input.Module.Status.Content,
-			ContentHash:      input.Module.Status.ContentHash,
-			RunHash:          runHash,
-			ExecutionVersion: state.Spec.Version,
+			ExecutionName:            state.Name,
+			AutoConfirm:              state.Spec.AutoConfirm,
+			Content:                  input.Module.Status.Content,
+			ContentHash:              input.Module.Status.ContentHash,
+			RunHash:                  runHash,
+			ExecutionVersion:         state.Spec.Version,
+			TerraformVersion:         state.Spec.TerraformVersion,
+			Engine:                   state.Spec.Engine,
+			Providers:                h.providers(state),
+			Workspace:                state.Spec.Workspace,
+			Operation:                op,
+			CostEstimation:           state.Spec.CostEstimation.DeepCopy(),
+			MaxMonthlyCostDelta:      state.Spec.MaxMonthlyCostDelta,
+			PolicySelector:           state.Spec.PolicySelector.DeepCopy(),
+			RequireApprovalOnDestroy: state.Spec.RequireApprovalOnDestroy,
+			ProtectedResourceTypes:   state.Spec.ProtectedResourceTypes,
+			Approval:                 state.Spec.Approval.DeepCopy(),
+			ChangedBy:                state.Status.ChangedBy,
 		},
 	}
 
@@ -215,6 +234,29 @@ func (h *Handler) createSecretForVariablesFile(or []metaV1.OwnerReference, name
 
 func (h *Handler) createJob(or []metaV1.OwnerReference, input *Input, runName, runHash, action, sa, namespace string, nodeSelector map[string]string) (*batchV1.Job, error) {
 	createEnvForJob(input, action, runName, namespace)
+	input.EnvVars = append(input.EnvVars, coreV1.EnvVar{
+		Name:  "EXECUTOR_CACHE_DIR",
+		Value: cacheDir,
+	})
+	if h.opts.TerraformMirror != "" {
+		input.EnvVars = append(input.EnvVars, coreV1.EnvVar{
+			Name:  "TERRAFORM_MIRROR",
+			Value: h.opts.TerraformMirror,
+		})
+	}
+	if h.opts.TofuMirror != "" {
+		input.EnvVars = append(input.EnvVars, coreV1.EnvVar{
+			Name:  "TOFU_MIRROR",
+			Value: h.opts.TofuMirror,
+		})
+	}
+	input.EnvVars = append(input.EnvVars, h.snapshotEnv()...)
+	if h.opts.RecordApprovals {
+		input.EnvVars = append(input.EnvVars, coreV1.EnvVar{
+			Name:  "APPROVALS_RECORDED",
+			Value: "true",
+		})
+	}
 
 	meta := metaV1.ObjectMeta{
 		Name:            "job-" + runName,
@@ -237,8 +279,15 @@ func (h *Handler) createJob(or []metaV1.OwnerReference, input *Input, runName, r
 							Name:  "agent",
 							Image: input.Image,
 							Env:   input.EnvVars,
+							VolumeMounts: []coreV1.VolumeMount{
+								{
+									Name:      cacheVolume,
+									MountPath: cacheDir,
+								},
+							},
 						},
 					},
+					Volumes:       []coreV1.Volume{h.cacheVolume()},
 					NodeSelector:  nodeSelector,
 					RestartPolicy: "Never",
 				},
@@ -258,6 +307,52 @@ func (h *Handler) createJob(or []metaV1.OwnerReference, input *Input, runName, r
 	return job, nil
 }
 
+// providers returns the provider installation settings of the state, falling back to
+// the controller's
+func (h *Handler) providers(state *v1.State) *v1.ProviderInstallation {
+	if state.Spec.Providers != nil {
+		return state.Spec.Providers.DeepCopy()
+	}
+	if h.opts.Providers.NetworkMirror == "" && h.opts.Providers.FilesystemMirror == "" {
+		return nil
+	}
+	return h.opts.Providers.DeepCopy()
+}
+
+// snapshotEnv configures the snapshot store of the executor, only the options that are set
+// are passed on
+func (h *Handler) snapshotEnv() []coreV1.EnvVar {
+	var env []coreV1.EnvVar
+	snapshots := h.opts.Snapshots
+	for _, v := range []struct{ name, value string }{
+		{"SNAPSHOT_STORE", snapshots.Store},
+		{"SNAPSHOT_S3_ENDPOINT", snapshots.S3Endpoint},
+		{"SNAPSHOT_S3_REGION", snapshots.S3Region},
+		{"SNAPSHOT_S3_BUCKET", snapshots.S3Bucket},
+		{"SNAPSHOT_S3_SECRET", snapshots.S3SecretName},
+	} {
+		if v.value != "" {
+			env = append(env, coreV1.EnvVar{Name: v.name, Value: v.value})
+		}
+	}
+	if snapshots.Retention > 0 {
+		env = append(env, coreV1.EnvVar{Name: "SNAPSHOT_RETENTION", Value: strconv.Itoa(snapshots.Retention)})
+	}
+	return env
+}
+
+func (h *Handler) cacheVolume() coreV1.Volume {
+	volume := coreV1.Volume{Name: cacheVolume}
+	if h.opts.CacheClaim != "" {
+		volume.PersistentVolumeClaim = &coreV1.PersistentVolumeClaimVolumeSource{
+			ClaimName: h.opts.CacheClaim,
+		}
+	} else {
+		volume.EmptyDir = &coreV1.EmptyDirVolumeSource{}
+	}
+	return volume
+}
+
 func (h *Handler) createServiceAccount(name, namespace string) (*coreV1.ServiceAccount, error) {
 	meta := metaV1.ObjectMeta{
 		Name:      "sa-" + name,
@@ -477,6 +572,19 @@ func generateRunHash(state *v1.State, vars map[string]string, h string, a string
 	if _, err := hash.Write([]byte(a)); err != nil {
 		logrus.Error("Failed to write to digest")
 	}
+	// only hashed when set so existing states keep their run hash
+	for _, kv := range [][2]string{
+		{"terraformVersion", state.Spec.TerraformVersion},
+		{"engine", state.Spec.Engine},
+		{"workspace", state.Spec.Workspace},
+	} {
+		if kv[1] == "" {
+			continue
+		}
+		if _, err := fmt.Fprintf(hash, "\x00%s=%s", kv[0], kv[1]); err != nil {
+			logrus.Error("Failed to write to digest")
+		}
+	}
 
 	encoding := hex.EncodeToString(hash.Sum(nil))[:10]
 
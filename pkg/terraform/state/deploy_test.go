@@ -0,0 +1,19 @@
+package state
+
+import (
+	"testing"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateRunHash(t *testing.T) {
+	vars := map[string]string{"name": "web"}
+	engine := &v1.State{Spec: v1.StateSpec{Engine: "tofu"}}
+	workspace := &v1.State{Spec: v1.StateSpec{Workspace: "tofu"}}
+	assert.NotEqual(t, generateRunHash(engine, vars, "hash", "create"), generateRunHash(workspace, vars, "hash", "create"))
+
+	split := &v1.State{Spec: v1.StateSpec{TerraformVersion: "1.0.1", Workspace: "1"}}
+	joined := &v1.State{Spec: v1.StateSpec{TerraformVersion: "1.0.11"}}
+	assert.NotEqual(t, generateRunHash(split, vars, "hash", "create"), generateRunHash(joined, vars, "hash", "create"))
+}
@@ -30,6 +30,14 @@ func (h *Handler) gatherInput(obj *v1.State) (*Input, bool, error) {
 		return nil, false, errors.New("module content hash is empty")
 	}
 
+	if mod.Status.ValidatedHash != mod.Status.ContentHash {
+		return nil, false, fmt.Errorf("module %s has not been validated", spec.ModuleName)
+	}
+
+	if v1.ModuleConditionValid.IsFalse(mod) {
+		return nil, false, fmt.Errorf("module %s is not valid: %s", spec.ModuleName, v1.ModuleConditionValid.GetMessage(mod))
+	}
+
 	secrets, ok, err := h.getSecrets(ns, spec)
 	if !ok || err != nil {
 		return nil, false, errors.New("pulling secrets failed")
@@ -10,7 +10,10 @@ import (
 	corev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	rbacv1 "github.com/rancher/wrangler/pkg/generated/controllers/rbac/v1"
 	"github.com/sirupsen/logrus"
+	coreV1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
@@ -18,8 +21,22 @@ const (
 	ActionCreate = "create"
 	//ActionDestroy for terraform
 	ActionDestroy = "destroy"
+	//ActionState runs the imports, moves and removes of an operation
+	ActionState = "state"
+	//ActionPlan only plans, without applying
+	ActionPlan = "plan"
+	//ActionRefresh applies a refresh-only plan
+	ActionRefresh = "refresh"
+	//ActionForceUnlock releases a stale backend lock
+	ActionForceUnlock = "force-unlock"
 	//Default Image
 	DefaultExecutorImage = "rancher/terraform-controller-executor"
+
+	cacheVolume = "cache"
+	cacheDir    = "/var/cache/terraform-controller"
+
+	reasonJobDeployed       = "JobDeployed"
+	reasonOperationRejected = "OperationRejected"
 )
 
 func NewHandler(
@@ -33,6 +50,9 @@ func NewHandler(
 	configMaps corev1.ConfigMapController,
 	serviceAccounts corev1.ServiceAccountController,
 	jobs batchv1.JobController,
+	leases coordinationv1.LeasesGetter,
+	recorder record.EventRecorder,
+	opts Options,
 ) *Handler {
 	return &Handler{
 		ctx:                 ctx,
@@ -45,9 +65,44 @@ func NewHandler(
 		configMaps:          configMaps,
 		serviceAccounts:     serviceAccounts,
 		jobs:                jobs,
+		leases:              leases,
+		recorder:            recorder,
+		opts:                opts,
 	}
 }
 
+// Options configure the executor jobs
+type Options struct {
+	// TerraformMirror is the url executors download terraform releases from when a state
+	// sets terraformVersion
+	TerraformMirror string
+	// TofuMirror is the url executors download OpenTofu releases from
+	TofuMirror string
+	// CacheClaim is a persistent volume claim mounted into every executor job to cache
+	// downloaded terraform releases and providers, executors use an empty dir when it is not set
+	CacheClaim string
+	// Providers are the provider installation settings of states that don't set their own
+	Providers v1.ProviderInstallation
+	// Snapshots configure where executors save a copy of the state after each apply
+	Snapshots SnapshotOptions
+	// RecordApprovals is set when the controller serves the admission webhook that records
+	// approvals, executors fail plans that wait for approvals otherwise
+	RecordApprovals bool
+}
+
+type SnapshotOptions struct {
+	// Store is "secret", "s3" or "none", executors default to secrets
+	Store string
+	// Retention is the number of snapshots kept per state
+	Retention int
+	// S3Endpoint, S3Region and S3Bucket locate the bucket of the s3 store
+	S3Endpoint string
+	S3Region   string
+	S3Bucket   string
+	// S3SecretName is a secret in the state's namespace with the accessKeyID and secretAccessKey
+	S3SecretName string
+}
+
 type Handler struct {
 	ctx                 context.Context
 	modules             tfv1.ModuleController
@@ -59,6 +114,9 @@ type Handler struct {
 	configMaps          corev1.ConfigMapController
 	serviceAccounts     corev1.ServiceAccountController
 	jobs                batchv1.JobController
+	leases              coordinationv1.LeasesGetter
+	recorder            record.EventRecorder
+	opts                Options
 }
 
 func (h *Handler) OnChange(key string, obj *v1.State) (*v1.State, error) {
@@ -72,6 +130,17 @@ func (h *Handler) OnChange(key string, obj *v1.State) (*v1.State, error) {
 		return nil, nil
 	}
 
+	if changed, err := h.updateLock(obj); err != nil {
+		logrus.Errorf("checking lock of state %s: %v", key, err)
+	} else if changed {
+		if obj, err = h.states.Update(obj); err != nil {
+			return obj, err
+		}
+	}
+	if obj.Status.Lock != nil {
+		h.states.EnqueueAfter(obj.Namespace, obj.Name, lockCheckInterval)
+	}
+
 	input, ok, err := h.gatherInput(obj)
 	if err != nil {
 		return obj, err
@@ -83,6 +152,33 @@ func (h *Handler) OnChange(key string, obj *v1.State) (*v1.State, error) {
 		return h.states.Update(obj)
 	}
 
+	if changed := checkVariables(obj, input); changed {
+		obj, err = h.states.Update(obj)
+		if err != nil {
+			return obj, err
+		}
+	}
+	if len(obj.Status.MissingVariables) > 0 {
+		logrus.Debugf("state %s is missing variables %v", key, obj.Status.MissingVariables)
+		return obj, nil
+	}
+
+	reason := ""
+	err = checkTerraformVersion(obj, input.Module)
+	if err != nil {
+		reason = reasonUnsupportedVersion
+	}
+	if !v1.StateConditionTerraformVersion.MatchesError(obj, reason, err) {
+		v1.StateConditionTerraformVersion.SetError(obj, reason, err)
+		if obj, err = h.states.Update(obj); err != nil {
+			return obj, err
+		}
+	}
+	if v1.StateConditionTerraformVersion.IsFalse(obj) {
+		logrus.Debugf("state %s: %s", key, v1.StateConditionTerraformVersion.GetMessage(obj))
+		return obj, nil
+	}
+
 	if v1.StateConditionJobDeployed.IsTrue(obj) && obj.Status.LastRunHash != "" {
 		logrus.Debugf("job already running %s, checking execution", obj.Status.LastRunHash)
 		execution, err := h.executions.Get(obj.Namespace, obj.Status.ExecutionName, metaV1.GetOptions{})
@@ -103,14 +199,31 @@ func (h *Handler) OnChange(key string, obj *v1.State) (*v1.State, error) {
 		}
 	}
 
-	if obj.Spec.Version < 1 {
-		obj.Spec.Version = 1
+	op, err := getOperation(obj)
+	if err == nil && op != nil && operationAction(op) == ActionForceUnlock {
+		err = checkForceUnlock(obj, op)
 	}
-
-	if obj.Spec.Version < 1 {
-		obj.Spec.Version = 1
+	if err == nil && op != nil && op.Restore != "" {
+		err = checkRestore(obj, op)
+	}
+	if err != nil {
+		h.recorder.Eventf(obj, coreV1.EventTypeWarning, reasonOperationRejected, "Operation rejected: %v", err)
+		delete(obj.Annotations, v1.StateOperationAnnotation)
+		if _, updateErr := h.states.Update(obj); updateErr != nil {
+			return obj, updateErr
+		}
+		return obj, err
+	}
+	if op != nil {
+		if v1.StateConditionJobDeployed.IsTrue(obj) && holdsState(operationAction(op)) {
+			logrus.Debugf("state %s has a running execution, waiting to run operation", key)
+			return obj, nil
+		}
+		return h.runOperation(obj, input, op)
 	}
 
+	SetDefaults(obj)
+
 	runHash := createRunHash(obj, input, ActionCreate)
 	if runHash == obj.Status.LastRunHash {
 		logrus.Debugf("last run hash is %s", runHash)
@@ -126,12 +239,8 @@ func (h *Handler) OnChange(key string, obj *v1.State) (*v1.State, error) {
 
 	logrus.Debug("lock acquired with new hash")
 
-	if obj.Spec.Image == "" {
-		obj.Spec.Image = fmt.Sprintf("%s:latest", DefaultExecutorImage)
-	}
-
 	//new execution if none running
-	exec, err := h.deployCreate(obj, input)
+	exec, err := h.deployCreate(obj, input, ActionCreate, nil)
 	if err != nil {
 		logrus.Debugf("failed to create execution for %s: %s", obj.Name, err)
 		return obj, err
@@ -146,6 +255,11 @@ func (h *Handler) OnChange(key string, obj *v1.State) (*v1.State, error) {
 
 func (h *Handler) OnRemove(key string, obj *v1.State) (*v1.State, error) {
 	logrus.Debugf("State On Remove Handler %s", key)
+	// removing the finalizer doesn't need the module, which may not be fetched or valid
+	if !obj.Spec.DestroyOnDelete || v1.StateConditionDestroyed.IsTrue(obj) {
+		return obj, nil
+	}
+
 	input, ok, err := h.gatherInput(obj)
 	if err != nil {
 		logrus.Debug("error gathering input")
@@ -161,10 +275,6 @@ func (h *Handler) OnRemove(key string, obj *v1.State) (*v1.State, error) {
 		return state, fmt.Errorf("missing info and can not run destroy")
 	}
 
-	if !obj.Spec.DestroyOnDelete || v1.StateConditionDestroyed.IsTrue(obj) {
-		return obj, nil
-	}
-
 	v1.ExecutionConditionMissingInfo.False(obj)
 
 	if v1.StateConditionJobDeployed.IsTrue(obj) && obj.Status.LastRunHash != "" {
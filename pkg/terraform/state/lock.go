@@ -0,0 +1,130 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// lockInfoAnnotation is where the kubernetes backend stores the lock info on its lease
+	lockInfoAnnotation = "app.terraform.io/lock-info"
+	// lockCheckInterval is how often a locked state is checked for a stale lock
+	lockCheckInterval = time.Minute
+)
+
+// lockInfo is the subset of terraform's statemgr.LockInfo shown in the state status
+type lockInfo struct {
+	ID        string
+	Operation string
+	Who       string
+	Created   time.Time
+}
+
+// leaseName is the name of the lease the kubernetes backend locks the state with
+func leaseName(state *v1.State) string {
+	workspace := state.Spec.Workspace
+	if workspace == "" {
+		workspace = "default"
+	}
+	return fmt.Sprintf("lock-tfstate-%s-%s", workspace, state.Name)
+}
+
+// updateLock records the backend lock of the state in its status, returning true if it changed.
+// The lease is only looked up while a job is deployed or a lock is recorded, the executor is the
+// one locking the backend.
+func (h *Handler) updateLock(state *v1.State) (bool, error) {
+	if !v1.StateConditionJobDeployed.IsTrue(state) && state.Status.Lock == nil {
+		return false, nil
+	}
+
+	lock, err := h.getLock(state)
+	if err != nil {
+		return false, err
+	}
+	if equality.Semantic.DeepEqual(lock, state.Status.Lock) {
+		return false, nil
+	}
+
+	if lock != nil && lock.Stale && (state.Status.Lock == nil || !state.Status.Lock.Stale) {
+		logrus.Warnf("state %s/%s is locked by job %s which no longer exists, lock %s can be force unlocked",
+			state.Namespace, state.Name, lock.Job, lock.ID)
+	}
+	state.Status.Lock = lock
+	return true, nil
+}
+
+// getLock returns the lock held on the state's lease, nil if the state is not locked
+func (h *Handler) getLock(state *v1.State) (*v1.StateLock, error) {
+	lease, err := h.leases.Leases(state.Namespace).Get(h.ctx, leaseName(state), metaV1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	data := lease.Annotations[lockInfoAnnotation]
+	if data == "" || lease.Spec.HolderIdentity == nil {
+		return nil, nil
+	}
+
+	info := lockInfo{}
+	if err := json.Unmarshal([]byte(data), &info); err != nil {
+		return nil, errors.Wrapf(err, "parsing lock info of lease %s", lease.Name)
+	}
+
+	lock := &v1.StateLock{
+		ID:        info.ID,
+		Operation: info.Operation,
+		Who:       info.Who,
+		Created:   metaV1.NewTime(info.Created),
+		Job:       holderJob(info.Who),
+	}
+	if lock.Job != "" {
+		_, err := h.jobs.Get(state.Namespace, lock.Job, metaV1.GetOptions{})
+		if k8sError.IsNotFound(err) {
+			lock.Stale = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return lock, nil
+}
+
+// holderJob returns the executor job that took a lock. Terraform records who as
+// user@hostname, and the hostname of a job's pod is the job name and a random suffix.
+func holderJob(who string) string {
+	host := who[strings.LastIndex(who, "@")+1:]
+	i := strings.LastIndex(host, "-")
+	if !strings.HasPrefix(host, "job-") || i <= len("job-") {
+		return ""
+	}
+	return host[:i]
+}
+
+// checkForceUnlock only allows releasing the lock currently held, named by its id, and only once
+// the job that holds it is gone
+func checkForceUnlock(state *v1.State, op *v1.Operation) error {
+	lock := state.Status.Lock
+	if lock == nil {
+		return fmt.Errorf("state %s is not locked", state.Name)
+	}
+	if op.LockID == "" {
+		return fmt.Errorf("force-unlock of state %s needs the id of the lock to release", state.Name)
+	}
+	if lock.ID != op.LockID {
+		return fmt.Errorf("lock %s is not held on state %s, the current lock is %s", op.LockID, state.Name, lock.ID)
+	}
+	if lock.Job != "" && !lock.Stale {
+		return fmt.Errorf("lock %s is held by job %s which is still running, delete the job first", lock.ID, lock.Job)
+	}
+	return nil
+}
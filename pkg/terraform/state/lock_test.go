@@ -0,0 +1,38 @@
+package state
+
+import (
+	"testing"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHolderJob(t *testing.T) {
+	assert.Equal(t, "job-vpc-x7k2p", holderJob("root@job-vpc-x7k2p-4fz8q"))
+	assert.Equal(t, "", holderJob("alice@laptop"))
+	assert.Equal(t, "", holderJob("root@job-"))
+	assert.Equal(t, "", holderJob(""))
+}
+
+func TestCheckForceUnlock(t *testing.T) {
+	state := &v1.State{}
+	state.Name = "vpc"
+	op := &v1.Operation{Action: ActionForceUnlock, LockID: "a1b2"}
+
+	assert.EqualError(t, checkForceUnlock(state, op), "state vpc is not locked")
+
+	state.Status.Lock = &v1.StateLock{ID: "c3d4", Job: "job-vpc-x7k2p"}
+	assert.EqualError(t, checkForceUnlock(state, op), "lock a1b2 is not held on state vpc, the current lock is c3d4")
+
+	state.Status.Lock.ID = ""
+	assert.EqualError(t, checkForceUnlock(state, &v1.Operation{Action: ActionForceUnlock}), "force-unlock of state vpc needs the id of the lock to release")
+
+	state.Status.Lock.ID = "a1b2"
+	assert.EqualError(t, checkForceUnlock(state, op), "lock a1b2 is held by job job-vpc-x7k2p which is still running, delete the job first")
+
+	state.Status.Lock.Stale = true
+	assert.NoError(t, checkForceUnlock(state, op))
+
+	state.Status.Lock = &v1.StateLock{ID: "a1b2", Who: "alice@laptop"}
+	assert.NoError(t, checkForceUnlock(state, op))
+}
@@ -0,0 +1,147 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// getOperation returns the one-shot operation requested through the operation annotation
+// of the state, nil if there is none. Operations the state's terraform version can't run are
+// rejected.
+func getOperation(state *v1.State) (*v1.Operation, error) {
+	op, err := parseOperation(state)
+	if op == nil || err != nil {
+		return nil, err
+	}
+	if err := checkOperationVersion(state, op); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func parseOperation(state *v1.State) (*v1.Operation, error) {
+	value, ok := state.Annotations[v1.StateOperationAnnotation]
+	if !ok || value == "" {
+		return nil, nil
+	}
+
+	op := &v1.Operation{}
+	if err := json.Unmarshal([]byte(value), op); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", v1.StateOperationAnnotation, err)
+	}
+	planning := len(op.Targets) > 0 || len(op.Replace) > 0
+	switch op.Action {
+	case "":
+	case ActionPlan, ActionRefresh:
+		if changesState(op) {
+			return nil, fmt.Errorf("invalid %s annotation: state changes can't be combined with action %s", v1.StateOperationAnnotation, op.Action)
+		}
+		if op.Action == ActionRefresh && len(op.Replace) > 0 {
+			return nil, fmt.Errorf("invalid %s annotation: replace can't be combined with action %s", v1.StateOperationAnnotation, op.Action)
+		}
+		return op, nil
+	case ActionForceUnlock:
+		if op.LockID == "" {
+			return nil, fmt.Errorf("invalid %s annotation: action %s needs a lockID", v1.StateOperationAnnotation, op.Action)
+		}
+		if planning || changesState(op) {
+			return nil, fmt.Errorf("invalid %s annotation: action %s can't be combined with other changes", v1.StateOperationAnnotation, op.Action)
+		}
+		return op, nil
+	default:
+		return nil, fmt.Errorf("invalid %s annotation: unknown action %q", v1.StateOperationAnnotation, op.Action)
+	}
+	if !planning && !changesState(op) {
+		return nil, fmt.Errorf("invalid %s annotation: no addresses to target, replace, import, move or remove", v1.StateOperationAnnotation)
+	}
+	if planning && changesState(op) {
+		return nil, fmt.Errorf("invalid %s annotation: state changes can't be combined with targets or replace", v1.StateOperationAnnotation)
+	}
+	if op.Restore != "" && (len(op.Imports) > 0 || len(op.Moves) > 0 || len(op.Removes) > 0) {
+		return nil, fmt.Errorf("invalid %s annotation: restore can't be combined with other state changes", v1.StateOperationAnnotation)
+	}
+	for _, i := range op.Imports {
+		if i.Address == "" || i.ID == "" {
+			return nil, fmt.Errorf("invalid %s annotation: imports need an address and id", v1.StateOperationAnnotation)
+		}
+	}
+	for _, m := range op.Moves {
+		if m.From == "" || m.To == "" {
+			return nil, fmt.Errorf("invalid %s annotation: moves need a from and to address", v1.StateOperationAnnotation)
+		}
+	}
+	return op, nil
+}
+
+// operationAction returns the executor action that runs the operation
+func operationAction(op *v1.Operation) string {
+	switch {
+	case changesState(op):
+		return ActionState
+	case op.Action != "":
+		return op.Action
+	default:
+		return ActionCreate
+	}
+}
+
+// holdsState is false for actions that run next to other executions: plans don't change
+// anything and force unlocks free a state whose execution is gone
+func holdsState(action string) bool {
+	return action != ActionPlan && action != ActionForceUnlock
+}
+
+// changesState is true for operations run with the state action rather than a plan
+func changesState(op *v1.Operation) bool {
+	return len(op.Imports) > 0 || len(op.Moves) > 0 || len(op.Removes) > 0 || op.Restore != ""
+}
+
+// checkRestore only allows restoring a snapshot listed in the status that was taken of the
+// state's current workspace
+func checkRestore(state *v1.State, op *v1.Operation) error {
+	for _, snapshot := range state.Status.Snapshots {
+		if snapshot.Name != op.Restore {
+			continue
+		}
+		if snapshot.Workspace != state.Spec.Workspace {
+			return fmt.Errorf("snapshot %s was taken of workspace %q, not the state's workspace %q", snapshot.Name, snapshot.Workspace, state.Spec.Workspace)
+		}
+		return nil
+	}
+	return fmt.Errorf("state %s has no snapshot %s", state.Name, op.Restore)
+}
+
+// runOperation deploys an execution for the operation and removes the annotation so it
+// only runs once
+func (h *Handler) runOperation(state *v1.State, input *Input, op *v1.Operation) (*v1.State, error) {
+	SetDefaults(state)
+
+	action := operationAction(op)
+	exec, err := h.deployCreate(state, input, action, op)
+	if err != nil {
+		logrus.Debugf("failed to create execution for operation on %s: %s", state.Name, err)
+		return state, err
+	}
+
+	delete(state.Annotations, v1.StateOperationAnnotation)
+	if action == ActionForceUnlock {
+		lock := state.Status.Lock
+		logrus.Infof("force unlocking state %s/%s with execution %s: lock %s for %s by %s since %s",
+			state.Namespace, state.Name, exec.Name, lock.ID, lock.Operation, lock.Who, lock.Created)
+	}
+	if !holdsState(action) {
+		return h.states.Update(state)
+	}
+	v1.StateConditionJobDeployed.True(state)
+	state.Status.ExecutionName = exec.Name
+	// targeted runs, refreshes and state changes leave the rest of the configuration as it was, so
+	// only a run of the whole configuration counts as the last run
+	if action == ActionCreate && len(op.Targets) == 0 {
+		state.Status.LastRunHash = createRunHash(state, input, ActionCreate)
+	}
+
+	return h.states.Update(state)
+}
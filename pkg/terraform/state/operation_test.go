@@ -0,0 +1,98 @@
+package state
+
+import (
+	"testing"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOperation(t *testing.T) {
+	state := &v1.State{}
+	op, err := getOperation(state)
+	assert.NoError(t, err)
+	assert.Nil(t, op)
+
+	state.Annotations = map[string]string{
+		v1.StateOperationAnnotation: `{"targets":["module.db"],"replace":["aws_instance.web[0]"]}`,
+	}
+	_, err = getOperation(state)
+	assert.Error(t, err, "the image's terraform has no -replace")
+
+	state.Spec.TerraformVersion = "1.0.11"
+	op, err = getOperation(state)
+	assert.NoError(t, err)
+	assert.Equal(t, &v1.Operation{
+		Targets: []string{"module.db"},
+		Replace: []string{"aws_instance.web[0]"},
+	}, op)
+
+	state.Annotations[v1.StateOperationAnnotation] = `{}`
+	_, err = getOperation(state)
+	assert.Error(t, err)
+
+	state.Annotations[v1.StateOperationAnnotation] = `aws_instance.web`
+	_, err = getOperation(state)
+	assert.Error(t, err)
+
+	state.Annotations[v1.StateOperationAnnotation] = `{"imports":[{"address":"aws_vpc.main","id":"vpc-0a1b"}],"removes":["aws_eip.old"]}`
+	op, err = getOperation(state)
+	assert.NoError(t, err)
+	assert.True(t, changesState(op))
+
+	state.Annotations[v1.StateOperationAnnotation] = `{"imports":[{"address":"aws_vpc.main"}]}`
+	_, err = getOperation(state)
+	assert.Error(t, err)
+
+	state.Annotations[v1.StateOperationAnnotation] = `{"targets":["module.db"],"moves":[{"from":"aws_vpc.a","to":"aws_vpc.b"}]}`
+	_, err = getOperation(state)
+	assert.Error(t, err)
+}
+
+func TestOperationAction(t *testing.T) {
+	state := &v1.State{}
+	state.Annotations = map[string]string{v1.StateOperationAnnotation: `{"action":"plan"}`}
+	op, err := getOperation(state)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionPlan, operationAction(op))
+
+	state.Annotations[v1.StateOperationAnnotation] = `{"action":"refresh","targets":["module.db"]}`
+	_, err = getOperation(state)
+	assert.Error(t, err, "the image's terraform has no -refresh-only")
+
+	state.Spec.TerraformVersion = "1.0.11"
+	op, err = getOperation(state)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionRefresh, operationAction(op))
+
+	assert.Equal(t, ActionCreate, operationAction(&v1.Operation{Replace: []string{"aws_instance.web"}}))
+	assert.Equal(t, ActionState, operationAction(&v1.Operation{Removes: []string{"aws_instance.web"}}))
+
+	for _, value := range []string{
+		`{"action":"apply"}`,
+		`{"action":"refresh","replace":["aws_instance.web"]}`,
+		`{"action":"plan","removes":["aws_instance.web"]}`,
+	} {
+		state.Annotations[v1.StateOperationAnnotation] = value
+		_, err = getOperation(state)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestCheckRestore(t *testing.T) {
+	state := &v1.State{}
+	state.Name = "vpc"
+	state.Spec.Workspace = "prod"
+	state.Status.Snapshots = []v1.StateSnapshot{
+		{Name: "snapshot-vpc-x7k2p", Workspace: "prod"},
+		{Name: "snapshot-vpc-4fz8q", Workspace: "staging"},
+	}
+
+	assert.NoError(t, checkRestore(state, &v1.Operation{Restore: "snapshot-vpc-x7k2p"}))
+	assert.Error(t, checkRestore(state, &v1.Operation{Restore: "snapshot-vpc-4fz8q"}))
+	assert.EqualError(t, checkRestore(state, &v1.Operation{Restore: "snapshot-vpc-missing"}), "state vpc has no snapshot snapshot-vpc-missing")
+
+	state.Annotations = map[string]string{v1.StateOperationAnnotation: `{"restore":"snapshot-vpc-x7k2p","removes":["aws_eip.old"]}`}
+	_, err := getOperation(state)
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package state
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+)
+
+const (
+	envVarPrefix           = "TF_VAR_"
+	reasonMissingVariables = "MissingVariables"
+)
+
+// checkVariables records the required module variables that are not set and the supplied
+// keys the module does not declare. Missing variables set the MissingInfo condition so no
+// job is started, it returns true when the state's status changed.
+func checkVariables(state *v1.State, input *Input) bool {
+	missing, unknown := compareVariables(input.Module.Status.Variables, suppliedVariables(input), envVariables(input))
+
+	before := state.Status.DeepCopy()
+	state.Status.MissingVariables = missing
+	state.Status.UnknownVariables = unknown
+
+	if len(missing) > 0 {
+		err := fmt.Errorf("module %s requires variables %s", input.Module.Name, strings.Join(missing, ", "))
+		v1.ExecutionConditionMissingInfo.SetError(state, reasonMissingVariables, err)
+	} else if v1.ExecutionConditionMissingInfo.GetReason(state) == reasonMissingVariables {
+		v1.ExecutionConditionMissingInfo.SetError(state, "", nil)
+	}
+
+	return !reflect.DeepEqual(before, &state.Status)
+}
+
+// suppliedVariables are the keys of the config maps and secrets of the state, the variables
+// written to the tfvars file. Data keys are not variables.
+func suppliedVariables(input *Input) map[string]bool {
+	supplied := map[string]bool{}
+	for k := range combineVars(input) {
+		supplied[k] = true
+	}
+	return supplied
+}
+
+// envVariables are the variables set through TF_VAR_ environment variables
+func envVariables(input *Input) map[string]bool {
+	env := map[string]bool{}
+	for _, e := range input.EnvVars {
+		if strings.HasPrefix(e.Name, envVarPrefix) {
+			env[strings.TrimPrefix(e.Name, envVarPrefix)] = true
+		}
+	}
+	return env
+}
+
+func compareVariables(declared []v1.ModuleVariable, supplied, env map[string]bool) (missing []string, unknown []string) {
+	known := map[string]bool{}
+	for _, v := range declared {
+		known[v.Name] = true
+		if v.Required && !supplied[v.Name] && !env[v.Name] {
+			missing = append(missing, v.Name)
+		}
+	}
+	for k := range supplied {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(unknown)
+	return missing, unknown
+}
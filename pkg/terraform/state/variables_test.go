@@ -0,0 +1,59 @@
+package state
+
+import (
+	"testing"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCompareVariables(t *testing.T) {
+	declared := []v1.ModuleVariable{
+		{Name: "do_token", Required: true},
+		{Name: "do_name", Required: true},
+		{Name: "region"},
+		{Name: "ssh_key", Required: true},
+	}
+	supplied := map[string]bool{
+		"do_token": true,
+		"do_nmae":  true,
+		"region":   true,
+	}
+	env := map[string]bool{
+		"ssh_key": true,
+	}
+
+	missing, unknown := compareVariables(declared, supplied, env)
+	assert.Equal(t, []string{"do_name"}, missing)
+	assert.Equal(t, []string{"do_nmae"}, unknown)
+
+	supplied["do_name"] = true
+	delete(supplied, "do_nmae")
+	missing, unknown = compareVariables(declared, supplied, env)
+	assert.Empty(t, missing)
+	assert.Empty(t, unknown)
+}
+
+func TestCheckVariablesIgnoresData(t *testing.T) {
+	state := &v1.State{
+		Spec: v1.StateSpec{Data: map[string]string{"do_token": "abc"}},
+	}
+	input := &Input{
+		Module: &v1.Module{
+			ObjectMeta: metaV1.ObjectMeta{Name: "droplet"},
+			Status: v1.ModuleStatus{
+				Variables: []v1.ModuleVariable{{Name: "do_token", Required: true}},
+			},
+		},
+	}
+
+	assert.True(t, checkVariables(state, input))
+	assert.Equal(t, []string{"do_token"}, state.Status.MissingVariables)
+	assert.True(t, v1.ExecutionConditionMissingInfo.IsFalse(state))
+
+	input.Secrets = []*coreV1.Secret{{Data: map[string][]byte{"do_token": []byte("abc")}}}
+	assert.True(t, checkVariables(state, input))
+	assert.Empty(t, state.Status.MissingVariables)
+}
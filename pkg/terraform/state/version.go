@@ -0,0 +1,80 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+)
+
+const (
+	reasonUnsupportedVersion = "UnsupportedVersion"
+	// defaultTerraformVersion is the terraform release of the executor image, run by states
+	// that don't set terraformVersion
+	defaultTerraformVersion = "0.14.2"
+	engineTofu              = "tofu"
+)
+
+var (
+	// replaceVersion is the first terraform release with plan -replace
+	replaceVersion = version.Must(version.NewVersion("0.15.2"))
+	// refreshOnlyVersion is the first terraform release with plan -refresh-only
+	refreshOnlyVersion = version.Must(version.NewVersion("0.15.4"))
+)
+
+// terraformVersion is the release the state runs, the executor image's terraform when
+// terraformVersion is empty
+func terraformVersion(state *v1.State) string {
+	if state.Spec.TerraformVersion == "" {
+		return defaultTerraformVersion
+	}
+	return state.Spec.TerraformVersion
+}
+
+// checkTerraformVersion returns an error when the terraform version of the state does
+// not satisfy the required_version constraints of the module. OpenTofu states without a
+// terraformVersion are not checked.
+func checkTerraformVersion(state *v1.State, module *v1.Module) error {
+	if state.Spec.Engine == engineTofu && state.Spec.TerraformVersion == "" {
+		return nil
+	}
+
+	name := terraformVersion(state)
+	v, err := version.NewVersion(name)
+	if err != nil {
+		return fmt.Errorf("invalid terraformVersion %q", name)
+	}
+
+	for _, required := range module.Status.RequiredVersions {
+		constraint, err := version.NewConstraint(required)
+		if err != nil {
+			return fmt.Errorf("module %s has an invalid required_version %q", module.Name, required)
+		}
+		if !constraint.Check(v) {
+			return fmt.Errorf("terraform %s does not satisfy required_version %q of module %s", v, required, module.Name)
+		}
+	}
+	return nil
+}
+
+// checkOperationVersion returns an error when the terraform version the state runs doesn't
+// have the flags the operation needs. Every OpenTofu release has them.
+func checkOperationVersion(state *v1.State, op *v1.Operation) error {
+	if state.Spec.Engine == engineTofu {
+		return nil
+	}
+
+	name := terraformVersion(state)
+	v, err := version.NewVersion(name)
+	if err != nil {
+		return fmt.Errorf("invalid terraformVersion %q", name)
+	}
+
+	if len(op.Replace) > 0 && v.LessThan(replaceVersion) {
+		return fmt.Errorf("replace needs terraform %s or later, state %s runs %s", replaceVersion, state.Name, v)
+	}
+	if op.Action == ActionRefresh && v.LessThan(refreshOnlyVersion) {
+		return fmt.Errorf("refresh needs terraform %s or later, state %s runs %s", refreshOnlyVersion, state.Name, v)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package state
+
+import (
+	"testing"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTerraformVersion(t *testing.T) {
+	module := &v1.Module{}
+	module.Name = "vpc"
+	module.Status.RequiredVersions = []string{">= 0.13", "< 2.0.0"}
+
+	state := &v1.State{}
+	assert.NoError(t, checkTerraformVersion(state, module))
+
+	module.Status.RequiredVersions = []string{">= 1.0"}
+	assert.EqualError(t, checkTerraformVersion(state, module), `terraform 0.14.2 does not satisfy required_version ">= 1.0" of module vpc`)
+	state.Spec.Engine = "tofu"
+	assert.NoError(t, checkTerraformVersion(state, module))
+	state.Spec.Engine = ""
+	module.Status.RequiredVersions = []string{">= 0.13", "< 2.0.0"}
+
+	state.Spec.TerraformVersion = "1.0.11"
+	assert.NoError(t, checkTerraformVersion(state, module))
+
+	state.Spec.TerraformVersion = "0.12.31"
+	assert.EqualError(t, checkTerraformVersion(state, module), `terraform 0.12.31 does not satisfy required_version ">= 0.13" of module vpc`)
+
+	state.Spec.TerraformVersion = "latest"
+	assert.Error(t, checkTerraformVersion(state, module))
+}
+
+func TestCheckOperationVersion(t *testing.T) {
+	state := &v1.State{}
+	state.Name = "web"
+	replace := &v1.Operation{Replace: []string{"aws_instance.web[0]"}}
+
+	assert.NoError(t, checkOperationVersion(state, &v1.Operation{Targets: []string{"module.db"}}))
+	assert.EqualError(t, checkOperationVersion(state, replace), "replace needs terraform 0.15.2 or later, state web runs 0.14.2")
+
+	state.Spec.TerraformVersion = "0.15.2"
+	assert.NoError(t, checkOperationVersion(state, replace))
+
+	refresh := &v1.Operation{Action: ActionRefresh}
+	assert.EqualError(t, checkOperationVersion(state, refresh), "refresh needs terraform 0.15.4 or later, state web runs 0.15.2")
+	state.Spec.TerraformVersion = "0.15.4"
+	assert.NoError(t, checkOperationVersion(state, refresh))
+
+	state.Spec.TerraformVersion = ""
+	state.Spec.Engine = "tofu"
+	assert.NoError(t, checkOperationVersion(state, replace))
+	assert.NoError(t, checkOperationVersion(state, refresh))
+}
@@ -0,0 +1,129 @@
+// Package validate checks terraform module content without running terraform
+package validate
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+)
+
+// Variable is an input variable declared by a module
+type Variable struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+	Sensitive   bool
+}
+
+// Output is an output declared by a module
+type Output struct {
+	Name        string
+	Description string
+	Sensitive   bool
+}
+
+// Result is what was found in a valid module
+type Result struct {
+	Variables []Variable
+	Outputs   []Output
+	// RequiredCore are the required_version constraints of the terraform blocks
+	RequiredCore []string
+	// Unformatted are the files "terraform fmt -check" would report
+	Unformatted []string
+}
+
+// Dir parses the terraform files in dir, returning an error describing the problems found
+// when the configuration can't be loaded
+func Dir(dir string) (*Result, error) {
+	if !tfconfig.IsModuleDir(dir) {
+		return nil, fmt.Errorf("no terraform configuration files found")
+	}
+
+	module, diags := tfconfig.LoadModule(dir)
+	if diags.HasErrors() {
+		return nil, diagnosticsError(dir, diags)
+	}
+
+	unformatted, err := checkFormat(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		RequiredCore: module.RequiredCore,
+		Unformatted:  unformatted,
+	}
+	for _, v := range module.Variables {
+		result.Variables = append(result.Variables, Variable{
+			Name:        v.Name,
+			Type:        v.Type,
+			Description: v.Description,
+			Required:    v.Required,
+			Sensitive:   v.Sensitive,
+		})
+	}
+	for _, o := range module.Outputs {
+		result.Outputs = append(result.Outputs, Output{
+			Name:        o.Name,
+			Description: o.Description,
+			Sensitive:   o.Sensitive,
+		})
+	}
+	sort.Slice(result.Variables, func(i, j int) bool {
+		return result.Variables[i].Name < result.Variables[j].Name
+	})
+	sort.Slice(result.Outputs, func(i, j int) bool {
+		return result.Outputs[i].Name < result.Outputs[j].Name
+	})
+
+	return result, nil
+}
+
+func diagnosticsError(dir string, diags tfconfig.Diagnostics) error {
+	var msgs []string
+	for _, diag := range diags {
+		if diag.Severity != tfconfig.DiagError {
+			continue
+		}
+		msg := diag.Summary
+		if diag.Detail != "" {
+			msg += ": " + diag.Detail
+		}
+		if diag.Pos != nil {
+			file, err := filepath.Rel(dir, diag.Pos.Filename)
+			if err != nil {
+				file = diag.Pos.Filename
+			}
+			msg = fmt.Sprintf("%s:%d: %s", file, diag.Pos.Line, msg)
+		}
+		msgs = append(msgs, msg)
+	}
+	return fmt.Errorf("%s", strings.Join(msgs, "; "))
+}
+
+// checkFormat returns the .tf files in dir that are not in canonical format
+func checkFormat(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+
+	var unformatted []string
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(src, hclwrite.Format(src)) {
+			unformatted = append(unformatted, filepath.Base(file))
+		}
+	}
+	return unformatted, nil
+}
@@ -0,0 +1,74 @@
+package validate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeModule(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "validate-test")
+	require.NoError(t, err)
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestDir(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"main.tf": `terraform {
+  required_version = ">= 0.13"
+}
+
+variable "name" {
+  type        = string
+  description = "droplet name"
+}
+
+variable "size" {
+  default = "s-1vcpu-1gb"
+}
+
+output "ip" {
+  value     = "127.0.0.1"
+  sensitive = true
+}
+`,
+		"unformatted.tf": "variable \"token\" {\n    sensitive = true\n    type = string\n}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	result, err := Dir(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Variable{
+		{Name: "name", Type: "string", Description: "droplet name", Required: true},
+		{Name: "size", Required: false},
+		{Name: "token", Type: "string", Required: true, Sensitive: true},
+	}, result.Variables)
+	assert.Equal(t, []Output{{Name: "ip", Sensitive: true}}, result.Outputs)
+	assert.Equal(t, []string{">= 0.13"}, result.RequiredCore)
+	assert.Equal(t, []string{"unformatted.tf"}, result.Unformatted)
+}
+
+func TestDirInvalid(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"main.tf": "variable \"name\" {\n  type = string\n",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := Dir(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "main.tf:")
+
+	empty := writeModule(t, nil)
+	defer os.RemoveAll(empty)
+
+	_, err = Dir(empty)
+	assert.Error(t, err)
+}
@@ -0,0 +1,240 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	ProviderGitHub    = "github"
+	ProviderGitLab    = "gitlab"
+	ProviderBitbucket = "bitbucket"
+	ProviderGitea     = "gitea"
+
+	refsHeads = "refs/heads/"
+	refsTags  = "refs/tags/"
+)
+
+var errNotPush = errors.New("event is not a push")
+
+// Push is the provider agnostic content of a push event
+type Push struct {
+	Provider string
+	// URLs are all the urls the repository is known by, clone/ssh/html
+	URLs     []string
+	Branches []string
+	Tags     []string
+}
+
+// detect returns the provider that sent the request based on its event header
+func detect(req *http.Request) string {
+	switch {
+	// gitea also sends the github headers so must be checked first
+	case req.Header.Get("X-Gitea-Event") != "":
+		return ProviderGitea
+	case req.Header.Get("X-GitHub-Event") != "":
+		return ProviderGitHub
+	case req.Header.Get("X-Gitlab-Event") != "":
+		return ProviderGitLab
+	case req.Header.Get("X-Event-Key") != "":
+		return ProviderBitbucket
+	}
+	return ""
+}
+
+// validate checks the request was sent with the shared secret. GitLab sends the secret as is,
+// the others sign the body with an HMAC.
+func validate(provider string, req *http.Request, body []byte, secret string) error {
+	if secret == "" {
+		return errors.New("no webhook secret configured")
+	}
+
+	switch provider {
+	case ProviderGitLab:
+		if subtle.ConstantTimeCompare([]byte(req.Header.Get("X-Gitlab-Token")), []byte(secret)) != 1 {
+			return errors.New("invalid gitlab token")
+		}
+		return nil
+	case ProviderGitea:
+		return validateHMAC(req.Header.Get("X-Gitea-Signature"), body, secret)
+	default:
+		sig := req.Header.Get("X-Hub-Signature-256")
+		if sig == "" {
+			sig = req.Header.Get("X-Hub-Signature")
+		}
+		if !strings.HasPrefix(sig, "sha256=") {
+			return errors.New("missing sha256 signature")
+		}
+		return validateHMAC(strings.TrimPrefix(sig, "sha256="), body, secret)
+	}
+}
+
+func validateHMAC(signature string, body []byte, secret string) error {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || len(expected) == 0 {
+		return errors.New("invalid signature")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func parse(provider string, req *http.Request, body []byte) (*Push, error) {
+	switch provider {
+	case ProviderGitHub, ProviderGitea:
+		event := req.Header.Get("X-GitHub-Event")
+		if provider == ProviderGitea {
+			event = req.Header.Get("X-Gitea-Event")
+		}
+		if event == "ping" {
+			return nil, errNotPush
+		}
+		if event != "push" {
+			return nil, fmt.Errorf("unsupported %s event %s", provider, event)
+		}
+		return parseGitHub(provider, body)
+	case ProviderGitLab:
+		event := req.Header.Get("X-Gitlab-Event")
+		if event != "Push Hook" && event != "Tag Push Hook" {
+			return nil, fmt.Errorf("unsupported gitlab event %s", event)
+		}
+		return parseGitLab(body)
+	case ProviderBitbucket:
+		event := req.Header.Get("X-Event-Key")
+		if event == "diagnostics:ping" {
+			return nil, errNotPush
+		}
+		if event != "repo:push" && event != "repo:refs_changed" {
+			return nil, fmt.Errorf("unsupported bitbucket event %s", event)
+		}
+		return parseBitbucket(body)
+	}
+	return nil, errors.New("unknown webhook provider")
+}
+
+type githubPush struct {
+	Ref        string `json:"ref"`
+	Repository struct {
+		CloneURL string `json:"clone_url"`
+		SSHURL   string `json:"ssh_url"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"repository"`
+}
+
+func parseGitHub(provider string, body []byte) (*Push, error) {
+	payload := githubPush{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	push := &Push{
+		Provider: provider,
+		URLs:     []string{payload.Repository.CloneURL, payload.Repository.SSHURL, payload.Repository.HTMLURL},
+	}
+	push.addRef(payload.Ref)
+	return push, nil
+}
+
+type gitlabPush struct {
+	Ref     string `json:"ref"`
+	Project struct {
+		GitHTTPURL string `json:"git_http_url"`
+		GitSSHURL  string `json:"git_ssh_url"`
+		WebURL     string `json:"web_url"`
+	} `json:"project"`
+}
+
+func parseGitLab(body []byte) (*Push, error) {
+	payload := gitlabPush{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	push := &Push{
+		Provider: ProviderGitLab,
+		URLs:     []string{payload.Project.GitHTTPURL, payload.Project.GitSSHURL, payload.Project.WebURL},
+	}
+	push.addRef(payload.Ref)
+	return push, nil
+}
+
+type bitbucketLink struct {
+	Href string `json:"href"`
+}
+
+// bitbucketPush covers both bitbucket cloud (push.changes) and server (changes) payloads
+type bitbucketPush struct {
+	Push struct {
+		Changes []struct {
+			New *struct {
+				Type string `json:"type"`
+				Name string `json:"name"`
+			} `json:"new"`
+		} `json:"changes"`
+	} `json:"push"`
+	Changes []struct {
+		RefID string `json:"refId"`
+		Type  string `json:"type"`
+	} `json:"changes"`
+	Repository struct {
+		Links struct {
+			HTML  bitbucketLink   `json:"html"`
+			Clone []bitbucketLink `json:"clone"`
+		} `json:"links"`
+	} `json:"repository"`
+}
+
+func parseBitbucket(body []byte) (*Push, error) {
+	payload := bitbucketPush{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	push := &Push{
+		Provider: ProviderBitbucket,
+		URLs:     []string{payload.Repository.Links.HTML.Href},
+	}
+	for _, clone := range payload.Repository.Links.Clone {
+		push.URLs = append(push.URLs, clone.Href)
+	}
+
+	for _, change := range payload.Push.Changes {
+		if change.New == nil {
+			continue
+		}
+		switch change.New.Type {
+		case "branch":
+			push.Branches = append(push.Branches, change.New.Name)
+		case "tag":
+			push.Tags = append(push.Tags, change.New.Name)
+		}
+	}
+	for _, change := range payload.Changes {
+		if change.Type == "DELETE" {
+			continue
+		}
+		push.addRef(change.RefID)
+	}
+
+	return push, nil
+}
+
+func (p *Push) addRef(ref string) {
+	switch {
+	case strings.HasPrefix(ref, refsHeads):
+		p.Branches = append(p.Branches, strings.TrimPrefix(ref, refsHeads))
+	case strings.HasPrefix(ref, refsTags):
+		p.Tags = append(p.Tags, strings.TrimPrefix(ref, refsTags))
+	}
+}
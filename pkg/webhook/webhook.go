@@ -0,0 +1,194 @@
+// Package webhook receives git push events and refreshes the Modules tracking the pushed ref
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	tfv1 "github.com/rancher/terraform-controller/pkg/generated/controllers/terraformcontroller.cattle.io/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// Path the receiver is served on
+	Path = "/hooks"
+
+	maxBodySize = 10 << 20
+)
+
+type Receiver struct {
+	modules tfv1.ModuleController
+	secret  string
+}
+
+func NewReceiver(modules tfv1.ModuleController, secret string) *Receiver {
+	return &Receiver{
+		modules: modules,
+		secret:  secret,
+	}
+}
+
+// ListenAndServe runs the receiver on addr until ctx is done
+func ListenAndServe(ctx context.Context, addr string, receiver *Receiver) error {
+	mux := http.NewServeMux()
+	mux.Handle(Path, receiver)
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	logrus.Infof("Listening for git webhooks on %s%s", addr, Path)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (r *Receiver) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxBodySize))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	provider := detect(req)
+	if provider == "" {
+		http.Error(rw, "unknown webhook provider", http.StatusBadRequest)
+		return
+	}
+
+	if err := validate(provider, req, body, r.secret); err != nil {
+		logrus.Warnf("rejected %s webhook: %v", provider, err)
+		http.Error(rw, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	push, err := parse(provider, req, body)
+	if err == errNotPush {
+		rw.WriteHeader(http.StatusOK)
+		return
+	} else if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	refreshed, err := r.refresh(push)
+	if err != nil {
+		logrus.Errorf("failed to refresh modules for %s webhook: %v", provider, err)
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(rw, "refreshed %d modules\n", refreshed)
+}
+
+// refresh marks every module tracking a pushed ref as out of date so the module handler
+// checks git right away instead of waiting for the next interval
+func (r *Receiver) refresh(push *Push) (int, error) {
+	modules, err := r.modules.Cache().List("", labels.Everything())
+	if err != nil {
+		return 0, err
+	}
+
+	refreshed := 0
+	for _, module := range modules {
+		if !matches(module, push) {
+			continue
+		}
+
+		module = module.DeepCopy()
+		v1.ModuleConditionGitUpdated.False(module)
+		if _, err := r.modules.Update(module); err != nil {
+			return refreshed, err
+		}
+
+		logrus.Infof("%s push received, refreshing module %s/%s", push.Provider, module.Namespace, module.Name)
+		refreshed++
+	}
+
+	return refreshed, nil
+}
+
+func matches(module *v1.Module, push *Push) bool {
+	git := module.Spec.Git
+	if len(module.Spec.Content) > 0 || git.URL == "" || git.Commit != "" {
+		return false
+	}
+
+	if !sameRepo(git.URL, push.URLs) {
+		return false
+	}
+
+	if git.Semver != "" {
+		return len(push.Tags) > 0
+	}
+
+	if git.Tag != "" {
+		return contains(push.Tags, git.Tag)
+	}
+
+	branch := git.Branch
+	if branch == "" {
+		branch = "master"
+	}
+	return contains(push.Branches, branch)
+}
+
+func sameRepo(moduleURL string, urls []string) bool {
+	normalized := normalizeURL(moduleURL)
+	for _, u := range urls {
+		if u != "" && normalizeURL(u) == normalized {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeURL reduces http(s), ssh and scp style git urls to host/path so the different urls
+// a repository is known by compare equal
+func normalizeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		if i := strings.Index(raw, ":"); i > 0 {
+			raw = "ssh://" + raw[:i] + "/" + raw[i+1:]
+		}
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return strings.ToLower(raw)
+	}
+
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	return strings.ToLower(u.Hostname() + "/" + path)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "github.com/rancher/terraform-controller/pkg/apis/terraformcontroller.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(body, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func module(url, branch, tag string) *v1.Module {
+	return &v1.Module{
+		Spec: v1.ModuleSpec{
+			ModuleContent: v1.ModuleContent{
+				Git: v1.GitLocation{URL: url, Branch: branch, Tag: tag},
+			},
+		},
+	}
+}
+
+func TestPushEvents(t *testing.T) {
+	const secret = "s3cr3t"
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		body     string
+		provider string
+		module   *v1.Module
+	}{
+		{
+			name:     "github",
+			headers:  map[string]string{"X-GitHub-Event": "push"},
+			body:     `{"ref":"refs/heads/main","repository":{"clone_url":"https://github.com/org/repo.git","ssh_url":"git@github.com:org/repo.git"}}`,
+			provider: ProviderGitHub,
+			module:   module("git@github.com:Org/Repo", "main", ""),
+		},
+		{
+			name:     "gitea",
+			headers:  map[string]string{"X-GitHub-Event": "push", "X-Gitea-Event": "push"},
+			body:     `{"ref":"refs/tags/v1.0.0","repository":{"clone_url":"https://gitea.example.com/org/repo.git"}}`,
+			provider: ProviderGitea,
+			module:   module("https://gitea.example.com/org/repo", "", "v1.0.0"),
+		},
+		{
+			name:     "gitlab",
+			headers:  map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": secret},
+			body:     `{"ref":"refs/heads/master","project":{"git_http_url":"https://gitlab.com/group/sub/repo.git"}}`,
+			provider: ProviderGitLab,
+			module:   module("https://gitlab.com/group/sub/repo.git", "", ""),
+		},
+		{
+			name:     "bitbucket cloud",
+			headers:  map[string]string{"X-Event-Key": "repo:push"},
+			body:     `{"push":{"changes":[{"new":{"type":"branch","name":"dev"}}]},"repository":{"links":{"html":{"href":"https://bitbucket.org/org/repo"}}}}`,
+			provider: ProviderBitbucket,
+			module:   module("https://user@bitbucket.org/org/repo.git", "dev", ""),
+		},
+		{
+			name:     "bitbucket server",
+			headers:  map[string]string{"X-Event-Key": "repo:refs_changed"},
+			body:     `{"changes":[{"refId":"refs/heads/master","type":"UPDATE"}],"repository":{"links":{"clone":[{"href":"ssh://git@bitbucket.example.com:7999/proj/repo.git","name":"ssh"}]}}}`,
+			provider: ProviderBitbucket,
+			module:   module("ssh://git@bitbucket.example.com:7999/proj/repo.git", "", ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", Path, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			provider := detect(req)
+			assert.Equal(t, tt.provider, provider)
+
+			if provider != ProviderGitLab {
+				assert.Error(t, validate(provider, req, []byte(tt.body), secret))
+				if provider == ProviderGitea {
+					req.Header.Set("X-Gitea-Signature", sign(tt.body, secret))
+				} else {
+					req.Header.Set("X-Hub-Signature-256", "sha256="+sign(tt.body, secret))
+				}
+			}
+			assert.NoError(t, validate(provider, req, []byte(tt.body), secret))
+			assert.Error(t, validate(provider, req, []byte(tt.body+" "), "other"))
+			assert.Error(t, validate(provider, req, []byte(tt.body), ""))
+
+			push, err := parse(provider, req, []byte(tt.body))
+			require.NoError(t, err)
+			assert.True(t, matches(tt.module, push))
+
+			other := tt.module.DeepCopy()
+			other.Spec.Git.Branch = "other"
+			other.Spec.Git.Tag = ""
+			assert.False(t, matches(other, push))
+
+			pinned := tt.module.DeepCopy()
+			pinned.Spec.Git.Commit = "abc"
+			assert.False(t, matches(pinned, push))
+		})
+	}
+}